@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// coverageAnalysis describes how well the selected controls cover the
+// threats the selected capabilities face
+type coverageAnalysis struct {
+	Capabilities       int                 `json:"capabilities"`
+	Threats            int                 `json:"threats"`
+	Controls           int                 `json:"controls"`
+	UnmitigatedThreats []unmitigatedThreat `json:"unmitigated-threats"`
+	SharedControls     []sharedControl     `json:"shared-controls"`
+	OrphanedControls   []orphanedControl   `json:"orphaned-controls"`
+	Families           []familyCoverage    `json:"families"`
+}
+
+// unmitigatedThreat is a threat in scope without an included control.
+// Controls lists the catalog's controls for it that were left out.
+type unmitigatedThreat struct {
+	Id           string   `json:"id"`
+	Title        string   `json:"title"`
+	Capabilities []string `json:"capabilities"`
+	Controls     []string `json:"controls"`
+}
+
+// sharedControl is an included control with the threats and capabilities
+// in scope it covers
+type sharedControl struct {
+	Id           string   `json:"id"`
+	Title        string   `json:"title"`
+	Threats      []string `json:"threats"`
+	Capabilities []string `json:"capabilities"`
+}
+
+// orphanedControl is a catalog control none of whose threats are in scope
+type orphanedControl struct {
+	Id      string   `json:"id"`
+	Title   string   `json:"title"`
+	Family  string   `json:"family"`
+	Threats []string `json:"threats"`
+}
+
+// familyCoverage counts a control family's controls: those mitigating a
+// threat in scope and those included in the output. Percent is the share
+// of the in-scope controls that are included.
+type familyCoverage struct {
+	Title    string  `json:"title"`
+	Total    int     `json:"total"`
+	InScope  int     `json:"in-scope"`
+	Included int     `json:"included"`
+	Percent  float64 `json:"percent"`
+}
+
+// analyzeCoverage computes the coverage of the selected set from the data
+// loaded by loadData
+func analyzeCoverage() coverageAnalysis {
+	capabilities, threats, controls := selectedIdentifiers()
+	analysis := coverageAnalysis{
+		Capabilities:       len(capabilities),
+		Threats:            len(threats),
+		Controls:           len(controls),
+		UnmitigatedThreats: []unmitigatedThreat{},
+		SharedControls:     []sharedControl{},
+		OrphanedControls:   []orphanedControl{},
+		Families:           []familyCoverage{},
+	}
+
+	unmitigated := make(map[string]*unmitigatedThreat)
+	shared := make(map[string]*sharedControl)
+	inScope := make(map[string]bool)
+	for _, i := range selectedCapabilities {
+		for _, threat := range i.capability.Threats {
+			if !slices.Contains(threats, threat.Data.Id) {
+				continue
+			}
+			mitigated := false
+			for _, control := range threat.Controls {
+				inScope[control.Data.Id] = true
+				if !slices.Contains(controls, control.Data.Id) {
+					continue
+				}
+				mitigated = true
+				c, ok := shared[control.Data.Id]
+				if !ok {
+					c = &sharedControl{Id: control.Data.Id, Title: singleLine(control.Data.Title)}
+					shared[control.Data.Id] = c
+				}
+				c.Threats = appendIfMissing(c.Threats, threat.Data.Id)
+				c.Capabilities = appendIfMissing(c.Capabilities, i.id)
+			}
+			if mitigated {
+				continue
+			}
+			t, ok := unmitigated[threat.Data.Id]
+			if !ok {
+				t = &unmitigatedThreat{Id: threat.Data.Id, Title: singleLine(threat.Data.Title), Controls: []string{}}
+				unmitigated[threat.Data.Id] = t
+			}
+			t.Capabilities = appendIfMissing(t.Capabilities, i.id)
+			for _, control := range threat.Controls {
+				t.Controls = appendIfMissing(t.Controls, control.Data.Id)
+			}
+		}
+	}
+	for _, t := range unmitigated {
+		sort.Strings(t.Capabilities)
+		sort.Strings(t.Controls)
+		analysis.UnmitigatedThreats = append(analysis.UnmitigatedThreats, *t)
+	}
+	sort.Slice(analysis.UnmitigatedThreats, func(i, j int) bool {
+		return analysis.UnmitigatedThreats[i].Id < analysis.UnmitigatedThreats[j].Id
+	})
+	for _, c := range shared {
+		sort.Strings(c.Threats)
+		sort.Strings(c.Capabilities)
+		analysis.SharedControls = append(analysis.SharedControls, *c)
+	}
+	sort.Slice(analysis.SharedControls, func(i, j int) bool {
+		a, b := analysis.SharedControls[i], analysis.SharedControls[j]
+		if len(a.Threats) != len(b.Threats) {
+			return len(a.Threats) > len(b.Threats)
+		}
+		if len(a.Capabilities) != len(b.Capabilities) {
+			return len(a.Capabilities) > len(b.Capabilities)
+		}
+		return a.Id < b.Id
+	})
+
+	for _, family := range catalog.ControlFamilies {
+		coverage := familyCoverage{Title: family.Title}
+		for _, control := range family.Controls {
+			if control.Id == "" {
+				continue
+			}
+			coverage.Total++
+			if slices.Contains(controls, control.Id) {
+				coverage.Included++
+			}
+			if inScope[control.Id] {
+				coverage.InScope++
+				continue
+			}
+			orphan := orphanedControl{
+				Id:      control.Id,
+				Title:   singleLine(control.Title),
+				Family:  family.Title,
+				Threats: []string{},
+			}
+			for _, mapping := range control.ThreatMappings {
+				if mapping.ReferenceId == catalogReferenceId {
+					orphan.Threats = append(orphan.Threats, mapping.Identifiers...)
+				}
+			}
+			analysis.OrphanedControls = append(analysis.OrphanedControls, orphan)
+		}
+		if coverage.Total == 0 {
+			continue
+		}
+		if coverage.InScope > 0 {
+			coverage.Percent = float64(coverage.Included) * 100 / float64(coverage.InScope)
+		}
+		analysis.Families = append(analysis.Families, coverage)
+	}
+	return analysis
+}
+
+// renderAnalysis describes the coverage analysis as text
+func renderAnalysis(analysis coverageAnalysis) string {
+	lines := []string{
+		fmt.Sprintf("%d capabilities face %d threats, mitigated by %d controls", analysis.Capabilities, analysis.Threats, analysis.Controls),
+		"",
+		fmt.Sprintf("Threats without controls (%d)", len(analysis.UnmitigatedThreats)),
+	}
+	for _, t := range analysis.UnmitigatedThreats {
+		reason := "the catalog has no controls for it"
+		if len(t.Controls) > 0 {
+			reason = "left out: " + strings.Join(t.Controls, ", ")
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %s (%s); %s", t.Id, t.Title, strings.Join(t.Capabilities, ", "), reason))
+	}
+
+	lines = append(lines, "", "Controls covering the most threats")
+	for _, c := range analysis.SharedControls {
+		lines = append(lines, fmt.Sprintf("  %s: %d threats, %d capabilities (%s)",
+			c.Id, len(c.Threats), len(c.Capabilities), strings.Join(c.Threats, ", ")))
+	}
+
+	lines = append(lines, "", fmt.Sprintf("Orphaned controls (%d)", len(analysis.OrphanedControls)))
+	for _, c := range analysis.OrphanedControls {
+		lines = append(lines, fmt.Sprintf("  %s: %s; threats %s not in scope", c.Id, c.Title, listOrNone(c.Threats)))
+	}
+
+	lines = append(lines, "", "Coverage by family")
+	for _, f := range analysis.Families {
+		if f.InScope == 0 {
+			lines = append(lines, fmt.Sprintf("  %s: none of %d controls in scope", f.Title, f.Total))
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  %s: %d of %d in-scope controls included (%.0f%%), %d controls in total",
+			f.Title, f.Included, f.InScope, f.Percent, f.Total))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runAnalyze prints the coverage analysis of a selection made with the
+// same flags as generate
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	asJson := fs.Bool("json", false, "Print the analysis as JSON")
+	var selection selectionOptions
+	selection.register(fs)
+	var sources sourceOptions
+	sources.register(fs)
+	cacheSettings.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cacheSettings.validate(); err != nil {
+		return err
+	}
+	if err := selection.validate(); err != nil {
+		return err
+	}
+
+	explanation, err := selection.apply(sources)
+	if err != nil {
+		return err
+	}
+	analysis := analyzeCoverage()
+	if *asJson {
+		data, err := json.MarshalIndent(analysis, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	for _, line := range explanation {
+		fmt.Println(line)
+	}
+	fmt.Println(renderAnalysis(analysis))
+	return nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+func TestFamilyCoverageOverInScopeControls(t *testing.T) {
+	threat := layer2.Threat{Id: "TH01", Title: "Interception"}
+	mitigating := layer2.Control{Id: "C01", AssessmentRequirements: []layer2.AssessmentRequirement{{Id: "C01.TR01"}}}
+	family := layer2.ControlFamily{Title: "Data", Controls: []layer2.Control{mitigating}}
+	for _, id := range []string{"C02", "C03", "C04", "C05"} {
+		family.Controls = append(family.Controls, layer2.Control{Id: id})
+	}
+	catalog = layer2.Catalog{ControlFamilies: []layer2.ControlFamily{family, {Title: "Logging", Controls: []layer2.Control{{Id: "C06"}}}}}
+	applicabilityLevel = layer2.Category{}
+	excludedThreats = map[string]bool{}
+	excludedControls = map[string]bool{}
+	selectedCapabilities = map[string]item{"F01": {
+		id: "F01",
+		capability: availableCapability{
+			Data:    layer2.Capability{Id: "F01"},
+			Threats: []availableThreat{{Data: threat, Controls: []availableControl{{Data: mitigating, FamilyTitle: "Data"}}}},
+		},
+	}}
+	t.Cleanup(func() { selectedCapabilities = map[string]item{} })
+
+	families := analyzeCoverage().Families
+	if len(families) != 2 {
+		t.Fatalf("got %d families, want 2", len(families))
+	}
+	want := familyCoverage{Title: "Data", Total: 5, InScope: 1, Included: 1, Percent: 100}
+	if families[0] != want {
+		t.Errorf("got %+v, want %+v", families[0], want)
+	}
+	want = familyCoverage{Title: "Logging", Total: 1}
+	if families[1] != want {
+		t.Errorf("got %+v, want %+v", families[1], want)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/revanite-io/sci/layer2"
+)
+
+// applicabilityItem is an applicability category offered when choosing the
+// level the output catalog is scoped to
+type applicabilityItem struct {
+	category layer2.Category
+}
+
+func (i applicabilityItem) Title() string {
+	if i.category.Id == "" {
+		return i.category.Title
+	}
+	return i.category.Id + ": " + i.category.Title
+}
+func (i applicabilityItem) Description() string { return singleLine(i.category.Description) }
+func (i applicabilityItem) FilterValue() string { return i.category.Title }
+
+// filteredRequirement is an assessment requirement of a selected control
+// that doesn't apply at the chosen applicability level
+type filteredRequirement struct {
+	control     availableControl
+	requirement layer2.AssessmentRequirement
+}
+
+// reason explains why the requirement was filtered out
+func (f filteredRequirement) reason() string {
+	if len(f.requirement.Applicability) == 0 {
+		return "no applicability listed"
+	}
+	return "applies to " + strings.Join(f.requirement.Applicability, ", ") + " only"
+}
+
+// applicabilityCategories returns the categories declared by the loaded
+// catalog, followed by any used by requirements without being declared
+func applicabilityCategories() []layer2.Category {
+	categories := append([]layer2.Category{}, catalog.Metadata.ApplicabilityCategories...)
+	declared := make(map[string]bool)
+	for _, category := range categories {
+		declared[category.Id] = true
+	}
+
+	var undeclared []string
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			for _, requirement := range control.AssessmentRequirements {
+				for _, id := range requirement.Applicability {
+					if !declared[id] {
+						declared[id] = true
+						undeclared = append(undeclared, id)
+					}
+				}
+			}
+		}
+	}
+	sort.Strings(undeclared)
+	for _, id := range undeclared {
+		categories = append(categories, layer2.Category{Id: id, Title: id})
+	}
+	return categories
+}
+
+// findApplicabilityCategory returns the category with the given ID
+func findApplicabilityCategory(id string) (layer2.Category, bool) {
+	for _, category := range applicabilityCategories() {
+		if category.Id == id {
+			return category, true
+		}
+	}
+	return layer2.Category{}, false
+}
+
+// applicabilityChoices lists the categories to choose from, preceded by
+// an option to skip filtering
+func applicabilityChoices() []list.Item {
+	items := []list.Item{applicabilityItem{category: layer2.Category{
+		Title:       "All",
+		Description: "Include every control and assessment requirement",
+	}}}
+	for _, category := range applicabilityCategories() {
+		items = append(items, applicabilityItem{category: category})
+	}
+	return items
+}
+
+// requirementApplies reports whether a requirement applies at the chosen
+// applicability level
+func requirementApplies(requirement layer2.AssessmentRequirement) bool {
+	return applicabilityLevel.Id == "" || slices.Contains(requirement.Applicability, applicabilityLevel.Id)
+}
+
+// controlApplies reports whether any of a control's requirements apply at
+// the chosen level. Controls without requirements can't be ruled out, so
+// they are kept.
+func controlApplies(control layer2.Control) bool {
+	if len(control.AssessmentRequirements) == 0 {
+		return true
+	}
+	for _, requirement := range control.AssessmentRequirements {
+		if requirementApplies(requirement) {
+			return true
+		}
+	}
+	return false
+}
+
+// filteredRequirements lists the requirements of selected, non-excluded
+// controls that the applicability level leaves out, ordered by ID
+func filteredRequirements() (filtered []filteredRequirement) {
+	seen := make(map[string]bool)
+	for _, i := range selectedCapabilities {
+		for _, threat := range i.capability.Threats {
+			if excludedThreats[threat.Data.Id] {
+				continue
+			}
+			for _, control := range threat.Controls {
+				if excludedControls[control.Data.Id] || seen[control.Data.Id] {
+					continue
+				}
+				seen[control.Data.Id] = true
+				for _, requirement := range control.Data.AssessmentRequirements {
+					if !requirementApplies(requirement) {
+						filtered = append(filtered, filteredRequirement{control: control, requirement: requirement})
+					}
+				}
+			}
+		}
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].requirement.Id < filtered[j].requirement.Id
+	})
+	return filtered
+}
+
+// renderFilteredRequirements describes what the applicability level left
+// out of the output catalog
+func renderFilteredRequirements() string {
+	if applicabilityLevel.Id == "" {
+		return "No applicability level chosen; every requirement is included."
+	}
+
+	filtered := filteredRequirements()
+	if len(filtered) == 0 {
+		return "Every requirement of the selected controls applies at " + applicabilityLevel.Id + "."
+	}
+
+	var lines []string
+	lines = append(lines, fmt.Sprintf("%d requirements don't apply at %s:", len(filtered), applicabilityLevel.Id), "")
+	for _, f := range filtered {
+		control := f.control.Data.Id
+		if !controlApplies(f.control.Data) {
+			control += ", control dropped"
+		}
+		lines = append(lines,
+			fmt.Sprintf("%s (%s): %s", f.requirement.Id, control, f.reason()),
+			"  "+singleLine(f.requirement.Text),
+			"",
+		)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// browseModes are the ways of walking the loaded catalog besides by
+// capability, in the order the browse key cycles through them
+var browseModes = []string{"threats", "controls"}
+
+// browseTitles are the list titles of the browse modes
+var browseTitles = map[string]string{
+	"threats":  "Browse by Threat",
+	"controls": "Browse by Control Family",
+}
+
+// browseItem is a threat, control family or control in one of the browse
+// modes. Family rows only group the controls below them.
+type browseItem struct {
+	id           string
+	title        string
+	family       string
+	isThreat     bool
+	capabilities []string
+	threats      []string
+	controls     []string
+}
+
+func (i browseItem) Title() string {
+	if i.id == "" {
+		return i.family
+	}
+	title := checkbox(i.included()) + " " + i.id + ": " + singleLine(i.title)
+	if !i.isThreat {
+		title = "  " + title
+	}
+	return title
+}
+
+func (i browseItem) Description() string {
+	if i.id == "" {
+		return i.title
+	}
+	details := []string{"Capabilities: " + listOrNone(i.capabilities)}
+	if i.isThreat {
+		details = append(details, "Controls: "+listOrNone(i.controls))
+	} else {
+		details = append(details, "Threats: "+listOrNone(i.threats))
+	}
+	if status := i.status(); status != "" {
+		details = append(details, status)
+	}
+	description := strings.Join(details, " · ")
+	if !i.isThreat {
+		description = "  " + description
+	}
+	return description
+}
+
+func (i browseItem) FilterValue() string { return i.title }
+
+// included reports whether the output catalog includes the threat or
+// control
+func (i browseItem) included() bool {
+	_, threats, controls := selectedIdentifiers()
+	if i.isThreat {
+		return slices.Contains(threats, i.id)
+	}
+	return slices.Contains(controls, i.id)
+}
+
+// status explains why a threat or control isn't included
+func (i browseItem) status() string {
+	switch {
+	case i.included():
+		return ""
+	case i.isThreat && excludedThreats[i.id], !i.isThreat && excludedControls[i.id]:
+		return "excluded"
+	case !i.isThreat && !controlAppliesById(i.id):
+		return "not applicable at " + applicabilityLevel.Id
+	case !slices.ContainsFunc(i.capabilities, isSelected):
+		return "no capability selected"
+	}
+	return "threat excluded"
+}
+
+func listOrNone(ids []string) string {
+	if len(ids) == 0 {
+		return "none"
+	}
+	return strings.Join(ids, ", ")
+}
+
+func isSelected(capabilityId string) bool {
+	_, ok := selectedCapabilities[capabilityId]
+	return ok
+}
+
+// controlAppliesById reports whether the control with the given ID applies
+// at the chosen level
+func controlAppliesById(id string) bool {
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			if control.Id == id {
+				return controlApplies(control)
+			}
+		}
+	}
+	return true
+}
+
+// threatBrowseItems lists the threats of the loaded catalog with the
+// capabilities exposed to them and the controls mitigating them
+func threatBrowseItems() (items []list.Item) {
+	byId := make(map[string]*browseItem)
+	var ids []string
+	for _, capability := range catalogContents {
+		for _, threat := range capability.Threats {
+			i, ok := byId[threat.Data.Id]
+			if !ok {
+				i = &browseItem{id: threat.Data.Id, title: threat.Data.Title, isThreat: true}
+				byId[threat.Data.Id] = i
+				ids = append(ids, threat.Data.Id)
+			}
+			i.capabilities = appendIfMissing(i.capabilities, capability.Data.Id)
+			for _, control := range threat.Controls {
+				i.controls = appendIfMissing(i.controls, control.Data.Id)
+			}
+		}
+	}
+
+	sort.Strings(ids)
+	for _, id := range ids {
+		sort.Strings(byId[id].capabilities)
+		sort.Strings(byId[id].controls)
+		items = append(items, *byId[id])
+	}
+	return items
+}
+
+// controlBrowseItems lists the controls of the loaded catalog under their
+// families, with the threats each mitigates and the capabilities facing
+// those threats
+func controlBrowseItems() (items []list.Item) {
+	byId := make(map[string]*browseItem)
+	for _, capability := range catalogContents {
+		for _, threat := range capability.Threats {
+			for _, control := range threat.Controls {
+				i, ok := byId[control.Data.Id]
+				if !ok {
+					i = &browseItem{id: control.Data.Id, title: control.Data.Title, family: control.FamilyTitle}
+					byId[control.Data.Id] = i
+				}
+				i.capabilities = appendIfMissing(i.capabilities, capability.Data.Id)
+				i.threats = appendIfMissing(i.threats, threat.Data.Id)
+			}
+		}
+	}
+
+	for _, family := range catalog.ControlFamilies {
+		var controls []list.Item
+		for _, control := range family.Controls {
+			if i, ok := byId[control.Id]; ok && i.family == family.Title {
+				sort.Strings(i.capabilities)
+				sort.Strings(i.threats)
+				controls = append(controls, *i)
+			}
+		}
+		if len(controls) == 0 {
+			continue
+		}
+		items = append(items, browseItem{family: family.Title, title: singleLine(family.Description)})
+		items = append(items, controls...)
+	}
+	return items
+}
+
+// toggleBrowseItem includes or excludes a threat or control like the
+// drill-down view does. Threats and controls no selected capability
+// reaches are brought in by selecting the capabilities they affect.
+func toggleBrowseItem(i browseItem, items []list.Item) string {
+	if i.id == "" {
+		return ""
+	}
+	excluded := excludedControls
+	if i.isThreat {
+		excluded = excludedThreats
+	}
+
+	switch {
+	case !slices.ContainsFunc(i.capabilities, isSelected):
+		selectCapabilityIds(i.capabilities, items)
+		delete(excluded, i.id)
+		return "Selected " + strings.Join(i.capabilities, ", ") + " for " + i.id
+	case excluded[i.id]:
+		delete(excluded, i.id)
+		return "Included " + i.id
+	default:
+		excluded[i.id] = true
+		return "Excluded " + i.id
+	}
+}
+
+// selectCapabilityIds selects the capabilities with the given IDs, taking
+// them from the capability list items when present
+func selectCapabilityIds(ids []string, items []list.Item) {
+	byId := make(map[string]item)
+	for _, capability := range catalogContents {
+		byId[capability.Data.Id] = item{
+			id:         capability.Data.Id,
+			title:      capability.Data.Title,
+			capability: capability,
+		}
+	}
+	for _, i := range items {
+		if i, ok := i.(item); ok {
+			byId[i.id] = i
+		}
+	}
+
+	for _, id := range ids {
+		if _, ok := selectedCapabilities[id]; !ok {
+			selectedCapabilities[id] = byId[id]
+		}
+	}
+}
@@ -1,66 +1,311 @@
 package main
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
+	"flag"
 	"fmt"
+	"io"
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/revanite-io/sci/layer2"
 	"gopkg.in/yaml.v3"
 )
 
-const cacheDir = "tmp"
+// The cache holds one metadata file per source URL under sourcesDir,
+// pointing at the fetched content stored by its hash under blobsDir.
+// Sources with identical content share a single blob.
+const (
+	sourcesDir = "sources"
+	blobsDir   = "blobs"
 
-// getCacheFilename generates a unique cache filename based on the URLs
-func getCacheFilename(urls []string) string {
-	// Sort URLs to ensure consistent hashing
-	urlStr := strings.Join(urls, "|")
-	hash := sha256.Sum256([]byte(urlStr))
-	return filepath.Join(cacheDir, "controls-canvas-"+hex.EncodeToString(hash[:8])+".yaml")
+	defaultCacheTTL = 24 * time.Hour
+)
+
+// cacheOptions controls how cached sources are reused
+type cacheOptions struct {
+	ttl     time.Duration
+	refresh bool
+	offline bool
 }
 
-// ensureCacheDir creates the cache directory if it doesn't exist
-func ensureCacheDir() error {
-	if _, err := os.Stat(cacheDir); os.IsNotExist(err) {
-		return os.MkdirAll(cacheDir, 0755)
+var cacheSettings = cacheOptions{ttl: defaultCacheTTL}
+
+func (o *cacheOptions) register(fs *flag.FlagSet) {
+	fs.DurationVar(&o.ttl, "cache-ttl", defaultCacheTTL, "How long cached catalogs are used before being revalidated")
+	fs.BoolVar(&o.refresh, "refresh", false, "Ignore cached catalogs and fetch them again")
+	fs.BoolVar(&o.offline, "offline", false, "Only use cached catalogs, never fetch")
+}
+
+func (o *cacheOptions) validate() error {
+	if o.refresh && o.offline {
+		return fmt.Errorf("--refresh and --offline cannot be used together")
 	}
 	return nil
 }
 
-// loadFromCache attempts to load catalog data from cache
-func loadFromCache(urls []string) (*layer2.Catalog, error) {
-	cacheFile := getCacheFilename(urls)
-	data, err := os.ReadFile(cacheFile)
+// cacheMetadata is stored for each cached source
+type cacheMetadata struct {
+	Url          string    `yaml:"url"`
+	ContentHash  string    `yaml:"content-hash"`
+	FetchedAt    time.Time `yaml:"fetched-at"`
+	ETag         string    `yaml:"etag,omitempty"`
+	LastModified string    `yaml:"last-modified,omitempty"`
+}
+
+// getCacheDir returns the directory cached sources are stored in
+func getCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
 	}
+	return filepath.Join(dir, "controls-canvas"), nil
+}
 
-	var catalog layer2.Catalog
-	err = yaml.Unmarshal(data, &catalog)
+// getMetadataFilename returns the metadata file for a source URL
+func getMetadataFilename(url string) (string, error) {
+	dir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, sourcesDir, hex.EncodeToString(hash[:8])+".yaml"), nil
+}
+
+// getBlobFilename returns where content with the given hash is stored
+func getBlobFilename(contentHash string) (string, error) {
+	dir, err := getCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, blobsDir, contentHash+".yaml"), nil
+}
+
+// hashContent returns the hex SHA-256 of a source's content
+func hashContent(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// loadCatalog assembles the catalog at urls from individually cached
+// sources, so sources shared between catalogs are only fetched once.
+// Cache problems that didn't stop the catalog from loading are returned
+// for the caller to show.
+func loadCatalog(urls []string) (loaded *layer2.Catalog, problems []error, err error) {
+	bodies := make([][]byte, len(urls))
+	for i, url := range urls {
+		body, problem, err := loadSource(url)
+		if err != nil {
+			return nil, problems, err
+		}
+		if problem != nil {
+			problems = append(problems, problem)
+		}
+		bodies[i] = body
+	}
+	loaded, err = decodeCatalog(urls, bodies)
+	return loaded, problems, err
+}
+
+// loadSource returns the content at url, using the cache according to
+// cacheSettings. Stale entries are revalidated with the source before use,
+// and local files are revalidated by their modification time every time,
+// so edits to them show up straight away.
+// problem is set when a stale copy had to be used or the content couldn't
+// be cached.
+func loadSource(url string) (body []byte, problem error, err error) {
+	cached, metadata, cacheErr := loadFromCache(url)
+
+	if cacheSettings.offline {
+		if cacheErr != nil {
+			return nil, nil, fmt.Errorf("offline mode: no cached copy of %s", url)
+		}
+		return cached, nil, nil
+	}
+
+	var validator cacheMetadata
+	if cacheErr == nil && !cacheSettings.refresh {
+		if isRemoteSource(url) && time.Since(metadata.FetchedAt) < cacheSettings.ttl {
+			return cached, nil, nil
+		}
+		validator = *metadata
+	}
+
+	body, fetched, notModified, err := fetchSource(url, validator)
+	if err != nil {
+		if cacheErr == nil && !cacheSettings.refresh {
+			return cached, fmt.Errorf("failed to revalidate %s, using stale copy: %w", url, err), nil
+		}
+		return nil, nil, err
+	}
+	if notModified {
+		body = cached
+	}
+
+	if err := saveToCache(body, fetched); err != nil {
+		problem = fmt.Errorf("failed to cache %s: %w", url, err)
+	}
+	return body, problem, nil
+}
+
+// isRemoteSource reports whether a source is fetched over HTTP rather than
+// read from disk
+func isRemoteSource(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// fetchSource reads a single catalog file. When the validator carries an
+// ETag or Last-Modified value and the source reports it unchanged, no body
+// is returned and notModified is set.
+func fetchSource(location string, validator cacheMetadata) (body []byte, metadata cacheMetadata, notModified bool, err error) {
+	metadata = cacheMetadata{Url: location}
+
+	if !isRemoteSource(location) {
+		info, err := os.Stat(location)
+		if err != nil {
+			return nil, metadata, false, fmt.Errorf("error opening file: %w", err)
+		}
+		metadata.LastModified = info.ModTime().UTC().Format(time.RFC3339Nano)
+		if validator.LastModified != "" && validator.LastModified == metadata.LastModified {
+			return nil, metadata, true, nil
+		}
+		body, err = os.ReadFile(location)
+		if err != nil {
+			return nil, metadata, false, fmt.Errorf("error reading file: %w", err)
+		}
+		return body, metadata, false, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, location, nil)
 	if err != nil {
-		return nil, err
+		return nil, metadata, false, fmt.Errorf("invalid URL %s: %w", location, err)
 	}
+	if validator.ETag != "" {
+		req.Header.Set("If-None-Match", validator.ETag)
+	}
+	if validator.LastModified != "" {
+		req.Header.Set("If-Modified-Since", validator.LastModified)
+	}
+
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, metadata, false, fmt.Errorf("failed to fetch URL: %w", err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		metadata.ETag, metadata.LastModified = validator.ETag, validator.LastModified
+		return nil, metadata, true, nil
+	case http.StatusOK:
+	default:
+		return nil, metadata, false, fmt.Errorf("failed to fetch %s; response status: %v", location, resp.Status)
+	}
+
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, metadata, false, fmt.Errorf("failed to read %s: %w", location, err)
+	}
+	metadata.ETag = resp.Header.Get("ETag")
+	metadata.LastModified = resp.Header.Get("Last-Modified")
+	return body, metadata, false, nil
+}
 
+// decodeCatalog decodes each source into a single catalog in order, the
+// same way layer2.Catalog.LoadFiles does
+func decodeCatalog(urls []string, bodies [][]byte) (*layer2.Catalog, error) {
+	var catalog layer2.Catalog
+	for i, body := range bodies {
+		decoder := yaml.NewDecoder(bytes.NewReader(body))
+		if err := decoder.Decode(&catalog); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("error decoding YAML: %w (%s)", err, urls[i])
+		}
+	}
 	return &catalog, nil
 }
 
-// saveToCache saves catalog data to cache
-func saveToCache(urls []string, catalog *layer2.Catalog) error {
+// ensureCacheDir creates the cache directories if they don't exist
+func ensureCacheDir() error {
+	dir, err := getCacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, sourcesDir), 0755); err != nil {
+		return err
+	}
+	return os.MkdirAll(filepath.Join(dir, blobsDir), 0755)
+}
+
+// loadFromCache attempts to load a source's content and metadata from
+// cache. Content that no longer matches its recorded hash is a miss.
+func loadFromCache(url string) ([]byte, *cacheMetadata, error) {
+	metadataFile, err := getMetadataFilename(url)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(metadataFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var metadata cacheMetadata
+	if err := yaml.Unmarshal(data, &metadata); err != nil {
+		return nil, nil, err
+	}
+
+	blobFile, err := getBlobFilename(metadata.ContentHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := os.ReadFile(blobFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	if hashContent(body) != metadata.ContentHash {
+		return nil, nil, fmt.Errorf("cached content for %s is corrupt", url)
+	}
+
+	return body, &metadata, nil
+}
+
+// saveToCache stores a source's content and metadata, stamped with the
+// current time
+func saveToCache(body []byte, metadata cacheMetadata) error {
 	if err := ensureCacheDir(); err != nil {
 		return fmt.Errorf("failed to create cache directory: %w", err)
 	}
 
-	data, err := yaml.Marshal(catalog)
+	metadata.ContentHash = hashContent(body)
+	metadata.FetchedAt = time.Now().UTC()
+
+	blobFile, err := getBlobFilename(metadata.ContentHash)
 	if err != nil {
-		return fmt.Errorf("failed to marshal catalog: %w", err)
+		return err
+	}
+	if _, err := os.Stat(blobFile); os.IsNotExist(err) {
+		if err := os.WriteFile(blobFile, body, 0644); err != nil {
+			return fmt.Errorf("failed to write cache file: %w", err)
+		}
 	}
 
-	cacheFile := getCacheFilename(urls)
-	if err := os.WriteFile(cacheFile, data, 0644); err != nil {
-		return fmt.Errorf("failed to write cache file: %w", err)
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache metadata: %w", err)
+	}
+	metadataFile, err := getMetadataFilename(metadata.Url)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(metadataFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to write cache metadata: %w", err)
 	}
 
 	return nil
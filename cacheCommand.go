@@ -0,0 +1,299 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+const cacheUsage = `Usage: controls-canvas cache <command> [arguments]
+
+Commands:
+  list              List cached catalog sources
+  show <id>         Show details of a cached source
+  clear [id...]     Remove the given cached sources, or all of them
+  prune             Remove cached sources older than --older-than`
+
+// cacheEntry describes one cached source on disk
+type cacheEntry struct {
+	id       string
+	path     string
+	blobPath string
+	size     int64
+	modTime  time.Time
+	metadata cacheMetadata
+	catalog  layer2.Catalog
+}
+
+// age returns how long ago the entry was fetched
+func (e cacheEntry) age() time.Duration {
+	if e.metadata.FetchedAt.IsZero() {
+		return time.Since(e.modTime)
+	}
+	return time.Since(e.metadata.FetchedAt)
+}
+
+// runCache dispatches the cache management subcommands
+func runCache(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, cacheUsage)
+		return flag.ErrHelp
+	}
+
+	switch args[0] {
+	case "list":
+		return runCacheList()
+	case "show":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: controls-canvas cache show <id>")
+		}
+		return runCacheShow(args[1])
+	case "clear":
+		return runCacheClear(args[1:])
+	case "prune":
+		return runCachePrune(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, cacheUsage)
+		return fmt.Errorf("unknown cache command %q", args[0])
+	}
+}
+
+func runCacheList() error {
+	entries, err := listCacheEntries()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		fmt.Println("No cached sources")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAGE\tSIZE\tTITLE\tVERSION\tSOURCE")
+	for _, e := range entries {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.id, formatAge(e.age()), formatSize(e.size),
+			e.catalog.Metadata.Title, e.catalog.Metadata.Version, e.metadata.Url)
+	}
+	return w.Flush()
+}
+
+func runCacheShow(id string) error {
+	e, err := findCacheEntry(id)
+	if err != nil {
+		return err
+	}
+
+	var controls int
+	for _, family := range e.catalog.ControlFamilies {
+		controls += len(family.Controls)
+	}
+
+	fmt.Printf("ID:            %s\n", e.id)
+	fmt.Printf("Source:        %s\n", e.metadata.Url)
+	fmt.Printf("Content:       %s\n", e.blobPath)
+	fmt.Printf("Content hash:  %s\n", e.metadata.ContentHash)
+	fmt.Printf("Size:          %s\n", formatSize(e.size))
+	fmt.Printf("Fetched:       %s (%s)\n", e.metadata.FetchedAt.Local().Format(time.RFC3339), formatAge(e.age()))
+	if e.metadata.ETag != "" {
+		fmt.Printf("ETag:          %s\n", e.metadata.ETag)
+	}
+	if e.metadata.LastModified != "" {
+		fmt.Printf("Last-Modified: %s\n", e.metadata.LastModified)
+	}
+	fmt.Printf("Title:         %s\n", e.catalog.Metadata.Title)
+	fmt.Printf("Version:       %s\n", e.catalog.Metadata.Version)
+	fmt.Printf("Capabilities:  %d\n", len(e.catalog.Capabilities))
+	fmt.Printf("Threats:       %d\n", len(e.catalog.Threats))
+	fmt.Printf("Controls:      %d in %d families\n", controls, len(e.catalog.ControlFamilies))
+	return nil
+}
+
+func runCacheClear(ids []string) error {
+	var entries []cacheEntry
+	if len(ids) == 0 {
+		all, err := listCacheEntries()
+		if err != nil {
+			return err
+		}
+		entries = all
+	}
+	for _, id := range ids {
+		e, err := findCacheEntry(id)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+
+	for _, e := range entries {
+		if err := removeCacheEntry(e); err != nil {
+			return err
+		}
+		fmt.Printf("Removed %s (%s)\n", e.id, e.metadata.Url)
+	}
+	return removeUnreferencedBlobs()
+}
+
+func runCachePrune(args []string) error {
+	fs := flag.NewFlagSet("cache prune", flag.ContinueOnError)
+	olderThan := fs.Duration("older-than", 7*24*time.Hour, "Remove sources fetched longer ago than this")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	entries, err := listCacheEntries()
+	if err != nil {
+		return err
+	}
+	removed := 0
+	for _, e := range entries {
+		if e.age() < *olderThan {
+			continue
+		}
+		if err := removeCacheEntry(e); err != nil {
+			return err
+		}
+		removed++
+	}
+	fmt.Printf("Removed %d of %d cached sources\n", removed, len(entries))
+	return removeUnreferencedBlobs()
+}
+
+// listCacheEntries reads every cached source, oldest first
+func listCacheEntries() ([]cacheEntry, error) {
+	dir, err := getCacheDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, sourcesDir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []cacheEntry
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		e := cacheEntry{
+			id:      strings.TrimSuffix(filepath.Base(file), ".yaml"),
+			path:    file,
+			modTime: info.ModTime(),
+		}
+		if data, err := os.ReadFile(file); err == nil {
+			_ = yaml.Unmarshal(data, &e.metadata)
+		}
+		if e.blobPath, err = getBlobFilename(e.metadata.ContentHash); err != nil {
+			return nil, err
+		}
+		if data, err := os.ReadFile(e.blobPath); err == nil {
+			e.size = int64(len(data))
+			_ = yaml.Unmarshal(data, &e.catalog)
+		}
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].age() > entries[j].age()
+	})
+	return entries, nil
+}
+
+// findCacheEntry returns the entry whose ID starts with id
+func findCacheEntry(id string) (cacheEntry, error) {
+	entries, err := listCacheEntries()
+	if err != nil {
+		return cacheEntry{}, err
+	}
+	var matches []cacheEntry
+	for _, e := range entries {
+		if strings.HasPrefix(e.id, id) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return cacheEntry{}, fmt.Errorf("no cached source matches %q", id)
+	case 1:
+		return matches[0], nil
+	default:
+		return cacheEntry{}, fmt.Errorf("%q matches %d cached sources", id, len(matches))
+	}
+}
+
+// removeCacheEntry deletes a cached source's metadata. Its content is
+// left for removeUnreferencedBlobs, as other sources may share it.
+func removeCacheEntry(e cacheEntry) error {
+	if err := os.Remove(e.path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", e.path, err)
+	}
+	return nil
+}
+
+// removeUnreferencedBlobs deletes stored content no cached source points at
+func removeUnreferencedBlobs() error {
+	entries, err := listCacheEntries()
+	if err != nil {
+		return err
+	}
+	referenced := make(map[string]bool)
+	for _, e := range entries {
+		referenced[e.blobPath] = true
+	}
+
+	dir, err := getCacheDir()
+	if err != nil {
+		return err
+	}
+	blobs, err := filepath.Glob(filepath.Join(dir, blobsDir, "*.yaml"))
+	if err != nil {
+		return err
+	}
+	for _, blob := range blobs {
+		if referenced[blob] {
+			continue
+		}
+		if err := os.Remove(blob); err != nil {
+			return fmt.Errorf("failed to remove %s: %w", blob, err)
+		}
+	}
+	return nil
+}
+
+// formatAge renders a duration the way a person would describe it
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	}
+}
+
+// formatSize renders a byte count with a binary unit
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
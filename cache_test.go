@@ -0,0 +1,179 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// useTempCache points the user cache directory at a fresh temporary
+// directory for the rest of the test
+func useTempCache(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("LocalAppData", dir)
+	cacheDir, err := getCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cacheDir
+}
+
+// cachedBlobs lists the content files in the cache
+func cachedBlobs(t *testing.T, cacheDir string) []string {
+	t.Helper()
+	blobs, err := filepath.Glob(filepath.Join(cacheDir, blobsDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return blobs
+}
+
+// backdateCacheEntry makes a cached source look fetched at the given time
+func backdateCacheEntry(t *testing.T, url string, fetchedAt time.Time) {
+	t.Helper()
+	_, metadata, err := loadFromCache(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata.FetchedAt = fetchedAt
+	data, err := yaml.Marshal(metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := getMetadataFilename(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(file, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSaveToCacheSharesContent(t *testing.T) {
+	cacheDir := useTempCache(t)
+
+	sources := map[string]string{
+		"https://example.com/a/threats.yaml": "threats: []\n",
+		"https://example.com/b/threats.yaml": "threats: []\n",
+		"https://example.com/controls.yaml":  "control-families: []\n",
+	}
+	for url, body := range sources {
+		if err := saveToCache([]byte(body), cacheMetadata{Url: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if blobs := cachedBlobs(t, cacheDir); len(blobs) != 2 {
+		t.Errorf("expected identical content to share a blob, got %d blobs", len(blobs))
+	}
+	for url, body := range sources {
+		cached, metadata, err := loadFromCache(url)
+		if err != nil {
+			t.Fatalf("loading %s: %v", url, err)
+		}
+		if string(cached) != body || metadata.Url != url || metadata.ContentHash != hashContent(cached) {
+			t.Errorf("%s: got %q with metadata %+v", url, cached, metadata)
+		}
+	}
+}
+
+func TestLoadFromCacheRejectsCorruptContent(t *testing.T) {
+	cacheDir := useTempCache(t)
+
+	url := "https://example.com/threats.yaml"
+	if err := saveToCache([]byte("threats: []\n"), cacheMetadata{Url: url}); err != nil {
+		t.Fatal(err)
+	}
+	blobs := cachedBlobs(t, cacheDir)
+	if len(blobs) != 1 {
+		t.Fatalf("expected one blob, got %d", len(blobs))
+	}
+	if err := os.WriteFile(blobs[0], []byte("tampered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := loadFromCache(url); err == nil {
+		t.Error("expected corrupt content to be a cache miss")
+	}
+}
+
+func TestPruneKeepsSharedContent(t *testing.T) {
+	cacheDir := useTempCache(t)
+
+	shared := []byte("threats: []\n")
+	old, recent, alone := "https://example.com/old.yaml", "https://example.com/recent.yaml", "https://example.com/alone.yaml"
+	for url, body := range map[string][]byte{old: shared, recent: shared, alone: []byte("capabilities: []\n")} {
+		if err := saveToCache(body, cacheMetadata{Url: url}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	backdateCacheEntry(t, old, time.Now().Add(-48*time.Hour))
+	backdateCacheEntry(t, alone, time.Now().Add(-48*time.Hour))
+
+	if err := runCachePrune([]string{"--older-than", "24h"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{old, alone} {
+		if _, _, err := loadFromCache(url); err == nil {
+			t.Errorf("expected %s to be pruned", url)
+		}
+	}
+	body, _, err := loadFromCache(recent)
+	if err != nil {
+		t.Fatalf("content shared with a pruned source was removed: %v", err)
+	}
+	if string(body) != string(shared) {
+		t.Errorf("got %q, want %q", body, shared)
+	}
+	if blobs := cachedBlobs(t, cacheDir); len(blobs) != 1 {
+		t.Errorf("expected only the shared blob to remain, got %d blobs", len(blobs))
+	}
+}
+
+func TestLoadSourceRevalidatesLocalFiles(t *testing.T) {
+	useTempCache(t)
+	defer func(settings cacheOptions) { cacheSettings = settings }(cacheSettings)
+	cacheSettings = cacheOptions{ttl: time.Hour}
+
+	file := filepath.Join(t.TempDir(), "threats.yaml")
+	write := func(body string, modTime time.Time) {
+		if err := os.WriteFile(file, []byte(body), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() string {
+		body, problem, err := loadSource(file)
+		if err != nil || problem != nil {
+			t.Fatalf("loadSource: %v, %v", err, problem)
+		}
+		return string(body)
+	}
+
+	write("first\n", time.Now().Add(-time.Hour))
+	if got := load(); got != "first\n" {
+		t.Fatalf("got %q on first load", got)
+	}
+	edited := time.Now()
+	write("second\n", edited)
+	if got := load(); got != "second\n" {
+		t.Errorf("expected the edited file within the TTL, got %q", got)
+	}
+	write("third\n", edited)
+	if got := load(); got != "second\n" {
+		t.Errorf("expected the cached copy while the modification time is unchanged, got %q", got)
+	}
+
+	cacheSettings.offline = true
+	os.Remove(file)
+	if got := load(); got != "second\n" {
+		t.Errorf("expected the cached copy offline, got %q", got)
+	}
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+type catalogItem struct {
+	id          string
+	title       string
+	description string
+	urls        []string
+	referenceId string
+	ref         string
+}
+
+func (i catalogItem) Title() string       { return i.title }
+func (i catalogItem) Description() string { return i.description }
+func (i catalogItem) FilterValue() string { return i.title }
+
+// refPlaceholder is replaced in catalog URLs by the catalog's pinned git ref
+const refPlaceholder = "{ref}"
+
+// defaultCatalogs returns the catalogs that ship with controls-canvas
+func defaultCatalogs() []catalogItem {
+	return []catalogItem{
+		{
+			id:          "ccc",
+			title:       "Common Cloud Controls",
+			description: "Default catalog with cloud security controls",
+			referenceId: "CCC",
+			ref:         "main",
+			urls: []string{
+				"https://raw.githubusercontent.com/finos/common-cloud-controls/{ref}/common/controls.yaml",
+				"https://raw.githubusercontent.com/finos/common-cloud-controls/{ref}/common/threats.yaml",
+				"https://raw.githubusercontent.com/finos/common-cloud-controls/{ref}/common/capabilities.yaml",
+			},
+		},
+	}
+}
+
+// findCatalog returns the catalog with the given ID, if present
+func findCatalog(catalogs []catalogItem, id string) (catalogItem, bool) {
+	for _, c := range catalogs {
+		if c.id == id {
+			return c, true
+		}
+	}
+	return catalogItem{}, false
+}
+
+// sourceUrls returns the catalog's URLs with the pinned ref substituted
+func (i catalogItem) sourceUrls() []string {
+	urls := make([]string, len(i.urls))
+	for n, u := range i.urls {
+		urls[n] = strings.ReplaceAll(u, refPlaceholder, i.ref)
+	}
+	return urls
+}
+
+// mergeCatalogs overlays entries onto base. Entries sharing an ID with a
+// base catalog override only the fields they set; the rest are appended.
+func mergeCatalogs(base, entries []catalogItem) []catalogItem {
+	merged := append([]catalogItem{}, base...)
+	for _, entry := range entries {
+		found := false
+		for n, existing := range merged {
+			if existing.id != entry.id {
+				continue
+			}
+			if entry.title != "" {
+				existing.title = entry.title
+			}
+			if entry.description != "" {
+				existing.description = entry.description
+			}
+			if len(entry.urls) > 0 {
+				existing.urls = entry.urls
+			}
+			if entry.referenceId != "" {
+				existing.referenceId = entry.referenceId
+			}
+			if entry.ref != "" {
+				existing.ref = entry.ref
+			}
+			merged[n] = existing
+			found = true
+			break
+		}
+		if !found {
+			merged = append(merged, entry)
+		}
+	}
+	return merged
+}
+
+// validateCatalog reports why a catalog cannot be loaded, if it can't
+func validateCatalog(c catalogItem) error {
+	if c.id == "" {
+		return fmt.Errorf("catalog %q has no id", c.title)
+	}
+	if c.title == "" {
+		return fmt.Errorf("catalog %q has no title", c.id)
+	}
+	if len(c.urls) == 0 {
+		return fmt.Errorf("catalog %q has no urls", c.id)
+	}
+	for _, u := range c.urls {
+		if strings.Contains(u, refPlaceholder) && c.ref == "" {
+			return fmt.Errorf("catalog %q uses %s in %s but sets no ref", c.id, refPlaceholder, u)
+		}
+		if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") && !filepath.IsAbs(u) {
+			return fmt.Errorf("catalog %q has unsupported location %s", c.id, u)
+		}
+		if !strings.Contains(u, ".yaml") && !strings.Contains(u, ".yml") {
+			return fmt.Errorf("catalog %q location %s is not a YAML file", c.id, u)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+// crosswalk shows which requirements of external frameworks the selected
+// controls cover, going by the guideline mappings of the loaded catalog
+type crosswalk struct {
+	Metadata   layer2.Metadata      `yaml:"metadata"`
+	Catalog    catalogReference     `yaml:"catalog"`
+	Frameworks []crosswalkFramework `yaml:"frameworks"`
+}
+
+type crosswalkFramework struct {
+	ReferenceId string               `yaml:"reference-id"`
+	Title       string               `yaml:"title,omitempty"`
+	Version     string               `yaml:"version,omitempty"`
+	Covered     int                  `yaml:"covered"`
+	Total       int                  `yaml:"total"`
+	Guidelines  []crosswalkGuideline `yaml:"guidelines"`
+}
+
+// crosswalkGuideline is a framework requirement with the selected controls
+// covering it and the catalog's other controls that could
+type crosswalkGuideline struct {
+	Id            string   `yaml:"id"`
+	Covered       bool     `yaml:"covered"`
+	Controls      []string `yaml:"controls,omitempty"`
+	OtherControls []string `yaml:"other-controls,omitempty"`
+}
+
+// buildCrosswalk groups the guideline mappings of every control in the
+// loaded catalog by framework, marking the guidelines the selected
+// controls map to as covered
+func buildCrosswalk() crosswalk {
+	_, _, selected := selectedIdentifiers()
+	guidelines := make(map[string]map[string]*crosswalkGuideline)
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			isSelected := slices.Contains(selected, control.Id)
+			for _, mapping := range control.GuidelineMappings {
+				if guidelines[mapping.ReferenceId] == nil {
+					guidelines[mapping.ReferenceId] = make(map[string]*crosswalkGuideline)
+				}
+				for _, id := range mapping.Identifiers {
+					id = strings.TrimSpace(id)
+					guideline := guidelines[mapping.ReferenceId][id]
+					if guideline == nil {
+						guideline = &crosswalkGuideline{Id: id}
+						guidelines[mapping.ReferenceId][id] = guideline
+					}
+					if isSelected {
+						guideline.Covered = true
+						guideline.Controls = appendIfMissing(guideline.Controls, control.Id)
+					} else {
+						guideline.OtherControls = appendIfMissing(guideline.OtherControls, control.Id)
+					}
+				}
+			}
+		}
+	}
+
+	references := make(map[string]layer2.MappingReference)
+	for _, reference := range catalog.Metadata.MappingReferences {
+		references[reference.Id] = reference
+	}
+
+	c := crosswalk{
+		Metadata:   catalogMetadata,
+		Catalog:    sourceCatalogReference(),
+		Frameworks: []crosswalkFramework{},
+	}
+	c.Metadata.ApplicabilityCategories = nil
+	c.Metadata.MappingReferences = nil
+	for referenceId, byId := range guidelines {
+		framework := crosswalkFramework{
+			ReferenceId: referenceId,
+			Title:       references[referenceId].Title,
+			Version:     references[referenceId].Version,
+			Total:       len(byId),
+		}
+		for _, guideline := range byId {
+			if guideline.Covered {
+				framework.Covered++
+			}
+			framework.Guidelines = append(framework.Guidelines, *guideline)
+		}
+		sort.Slice(framework.Guidelines, func(i, j int) bool {
+			return framework.Guidelines[i].Id < framework.Guidelines[j].Id
+		})
+		c.Frameworks = append(c.Frameworks, framework)
+	}
+	sort.Slice(c.Frameworks, func(i, j int) bool {
+		return c.Frameworks[i].ReferenceId < c.Frameworks[j].ReferenceId
+	})
+	return c
+}
+
+// renderCrosswalkFiles renders the crosswalk as YAML
+func renderCrosswalkFiles(path string) ([]outputFile, error) {
+	data, err := yaml.Marshal(buildCrosswalk())
+	if err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: data}}, nil
+}
+
+// renderCrosswalk describes the crosswalk for the crosswalk screen
+func renderCrosswalk() string {
+	c := buildCrosswalk()
+	if len(c.Frameworks) == 0 {
+		return "The catalog's controls don't map to any external frameworks."
+	}
+
+	var lines []string
+	for _, framework := range c.Frameworks {
+		heading := framework.ReferenceId
+		if framework.Title != "" {
+			heading += " (" + strings.TrimSpace(framework.Title+" "+framework.Version) + ")"
+		}
+		lines = append(lines, detailHeadingStyle.Render(fmt.Sprintf("%s: %d of %d covered", heading, framework.Covered, framework.Total)))
+		for _, guideline := range framework.Guidelines {
+			if guideline.Covered {
+				lines = append(lines, fmt.Sprintf("  %s %s  %s", checkbox(true), guideline.Id, strings.Join(guideline.Controls, ", ")))
+			} else {
+				lines = append(lines, detailLabelStyle.Render(fmt.Sprintf("  %s %s  not covered; mapped by %s",
+					checkbox(false), guideline.Id, strings.Join(guideline.OtherControls, ", "))))
+			}
+		}
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
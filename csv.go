@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+)
+
+var matrixHeader = []string{
+	"capability_id", "capability_title",
+	"threat_id", "threat_title",
+	"control_id", "control_title", "control_family",
+	"requirement_id", "requirement_text", "applicability",
+	"selected", "note",
+}
+
+// renderCsvFiles renders every capability of the loaded catalog as a CSV
+// matrix with one row per capability, threat, control and assessment
+// requirement, marking the rows the output catalog includes
+func renderCsvFiles(path string) ([]outputFile, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(matrixHeader); err != nil {
+		return nil, err
+	}
+	for _, row := range matrixRows() {
+		if err := w.Write(row); err != nil {
+			return nil, err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: buf.Bytes()}}, nil
+}
+
+// matrixRows flattens the loaded catalog into rows. Capabilities without
+// threats, threats without controls and controls without requirements
+// still get a row of their own.
+func matrixRows() (rows [][]string) {
+	for _, capability := range catalogContents {
+		_, selected := selectedCapabilities[capability.Data.Id]
+		note := ""
+		if !selected {
+			note = "capability not selected"
+		}
+		capabilityCells := []string{capability.Data.Id, singleLine(capability.Data.Title)}
+		if len(capability.Threats) == 0 {
+			rows = append(rows, matrixRow(capabilityCells, nil, nil, nil, selected, note))
+		}
+
+		for _, threat := range capability.Threats {
+			threatNote := note
+			if threatNote == "" && excludedThreats[threat.Data.Id] {
+				threatNote = "threat excluded"
+			}
+			threatCells := []string{threat.Data.Id, singleLine(threat.Data.Title)}
+			if len(threat.Controls) == 0 {
+				rows = append(rows, matrixRow(capabilityCells, threatCells, nil, nil, threatNote == "", threatNote))
+			}
+
+			for _, control := range threat.Controls {
+				controlNote := threatNote
+				switch {
+				case controlNote != "":
+				case excludedControls[control.Data.Id]:
+					controlNote = "control excluded"
+				case !controlApplies(control.Data):
+					controlNote = "control not applicable at " + applicabilityLevel.Id
+				}
+				controlCells := []string{control.Data.Id, singleLine(control.Data.Title), control.FamilyTitle}
+				if len(control.Data.AssessmentRequirements) == 0 {
+					rows = append(rows, matrixRow(capabilityCells, threatCells, controlCells, nil, controlNote == "", controlNote))
+				}
+
+				for _, requirement := range control.Data.AssessmentRequirements {
+					requirementNote := controlNote
+					if requirementNote == "" && !requirementApplies(requirement) {
+						requirementNote = "requirement not applicable at " + applicabilityLevel.Id
+					}
+					requirementCells := []string{
+						requirement.Id,
+						singleLine(requirement.Text),
+						strings.Join(requirement.Applicability, " "),
+					}
+					rows = append(rows, matrixRow(capabilityCells, threatCells, controlCells, requirementCells, requirementNote == "", requirementNote))
+				}
+			}
+		}
+	}
+	return rows
+}
+
+// matrixRow joins the cells of each level, padding missing levels so
+// every row has a column for each header
+func matrixRow(capability, threat, control, requirement []string, selected bool, note string) []string {
+	row := append([]string{}, capability...)
+	row = append(row, padCells(threat, 2)...)
+	row = append(row, padCells(control, 3)...)
+	row = append(row, padCells(requirement, 3)...)
+	if selected {
+		row = append(row, "yes")
+	} else {
+		row = append(row, "no")
+	}
+	return append(row, note)
+}
+
+// padCells returns cells, or n empty cells when there are none
+func padCells(cells []string, n int) []string {
+	if cells == nil {
+		return make([]string, n)
+	}
+	return cells
+}
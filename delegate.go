@@ -1,8 +1,6 @@
 package main
 
 import (
-	"strings"
-
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
 	tea "github.com/charmbracelet/bubbletea"
@@ -39,7 +37,7 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 			switch {
 			case key.Matches(msg, keys.choose):
 				if i, ok := model.SelectedItem().(item); ok {
-					capabilityId := strings.Split(i.title, ":")[0]
+					capabilityId := i.id
 
 					if _, ok := selectedCapabilities[capabilityId]; ok {
 						if _, ok := triedToReselectCapability[capabilityId]; ok {
@@ -54,7 +52,7 @@ func newItemDelegate(keys *delegateKeyMap) list.DefaultDelegate {
 
 			case msg.Type == tea.KeyBackspace || msg.Type == tea.KeyDelete:
 				if i, ok := model.SelectedItem().(item); ok {
-					capabilityId := strings.Split(i.title, ":")[0]
+					capabilityId := i.id
 					if _, ok := selectedCapabilities[capabilityId]; ok {
 						delete(selectedCapabilities, capabilityId)
 						delete(triedToReselectCapability, capabilityId)
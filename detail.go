@@ -0,0 +1,122 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	detailHeadingStyle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#25A065"))
+	detailLabelStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#A8A8A8"))
+)
+
+// renderCapabilityDetail renders a capability as a tree of the threats it
+// faces, the controls mitigating each threat and their assessment
+// requirements, wrapped to width
+func renderCapabilityDetail(capability availableCapability, width int) string {
+	var lines []string
+	lines = append(lines, detailHeadingStyle.Render(capability.Data.Id+": "+singleLine(capability.Data.Title)))
+	lines = append(lines, wrapBlock("", capability.Data.Description, width)...)
+
+	if len(capability.Threats) == 0 {
+		lines = append(lines, "", detailLabelStyle.Render("No threats are mapped to this capability."))
+	}
+
+	for t, threat := range capability.Threats {
+		branch, stem := treeBranch(t == len(capability.Threats)-1)
+		lines = append(lines, "│")
+		lines = append(lines, wrapNode(branch, stem+continuation(len(threat.Controls) > 0),
+			detailHeadingStyle.Render(threat.Data.Id+": "+singleLine(threat.Data.Title))+
+				statusSuffix(excludedThreats[threat.Data.Id], true), width)...)
+		lines = append(lines, wrapBlock(stem+continuation(len(threat.Controls) > 0), threat.Data.Description, width)...)
+
+		for c, control := range threat.Controls {
+			controlBranch, controlStem := treeBranch(c == len(threat.Controls)-1)
+			lines = append(lines, stem+"│")
+			body := stem + controlStem + continuation(len(control.Data.AssessmentRequirements) > 0)
+			lines = append(lines, wrapNode(stem+controlBranch, body,
+				detailHeadingStyle.Render(control.Data.Id+": "+singleLine(control.Data.Title))+
+					statusSuffix(excludedControls[control.Data.Id], controlApplies(control.Data)), width)...)
+			lines = append(lines, wrapBlock(body, detailLabelStyle.Render("Family: ")+control.FamilyTitle, width)...)
+			lines = append(lines, wrapBlock(body, control.FamilyDescription, width)...)
+			lines = append(lines, wrapBlock(body, detailLabelStyle.Render("Objective: ")+singleLine(control.Data.Objective), width)...)
+
+			for r, requirement := range control.Data.AssessmentRequirements {
+				requirementBranch, requirementStem := treeBranch(r == len(control.Data.AssessmentRequirements)-1)
+				heading := requirement.Id
+				if len(requirement.Applicability) > 0 {
+					heading += " [" + strings.Join(requirement.Applicability, ", ") + "]"
+				}
+				requirementBody := stem + controlStem + requirementStem
+				lines = append(lines, wrapNode(stem+controlStem+requirementBranch, requirementBody,
+					heading+statusSuffix(false, requirementApplies(requirement)), width)...)
+				lines = append(lines, wrapBlock(requirementBody, requirement.Text, width)...)
+				if requirement.Recommendation != "" {
+					lines = append(lines, wrapBlock(requirementBody, detailLabelStyle.Render("Recommendation: ")+singleLine(requirement.Recommendation), width)...)
+				}
+			}
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// treeBranch returns the connector for a tree node and the prefix that
+// continues beneath it
+func treeBranch(last bool) (branch, stem string) {
+	if last {
+		return "└─ ", "   "
+	}
+	return "├─ ", "│  "
+}
+
+// continuation returns the prefix for text beneath a tree node, keeping
+// the line to its children when it has any
+func continuation(hasChildren bool) string {
+	if hasChildren {
+		return "│  "
+	}
+	return "   "
+}
+
+// statusSuffix marks tree nodes that won't appear in the output catalog
+func statusSuffix(excluded, applies bool) string {
+	switch {
+	case excluded:
+		return detailLabelStyle.Render(" (excluded)")
+	case !applies:
+		return detailLabelStyle.Render(" (not applicable at " + applicabilityLevel.Id + ")")
+	}
+	return ""
+}
+
+// wrapBlock word-wraps text to fit beside prefix within width, repeating
+// prefix on every line
+func wrapBlock(prefix, text string, width int) []string {
+	return wrapNode(prefix, prefix, text, width)
+}
+
+// wrapNode word-wraps text to fit within width, starting the first line
+// with branch and the rest with stem
+func wrapNode(branch, stem, text string, width int) []string {
+	text = singleLine(text)
+	if text == "" {
+		return nil
+	}
+	available := width - lipgloss.Width(stem)
+	if available < 20 {
+		available = 20
+	}
+	wrapped := lipgloss.NewStyle().Width(available).Render(text)
+
+	var lines []string
+	for n, line := range strings.Split(wrapped, "\n") {
+		prefix := stem
+		if n == 0 {
+			prefix = branch
+		}
+		lines = append(lines, prefix+strings.TrimRight(line, " "))
+	}
+	return lines
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffAddedStyle   = lipgloss.NewStyle().Foreground(lipgloss.Color("#25A065"))
+	diffRemovedStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#FF5F5F"))
+	diffHunkStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#874BFD"))
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+type diffOp int
+
+const (
+	diffEqual diffOp = iota
+	diffAdded
+	diffRemoved
+)
+
+type diffLine struct {
+	op   diffOp
+	text string
+}
+
+// noNewline marks text whose last line isn't terminated, so adding or
+// removing the final newline shows up as a change
+const noNewline = `\ No newline at end of file`
+
+// splitLines splits text into lines, with no lines for empty text
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	if !strings.HasSuffix(text, "\n") {
+		return append(strings.Split(text, "\n"), noNewline)
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+// maxDiffCells bounds the size of the LCS table diffLines builds. Beyond
+// it the changed lines are shown as replaced wholesale, so diffing against
+// a large unrelated file doesn't take quadratic memory.
+const maxDiffCells = 1 << 22
+
+// diffLines computes a line diff between before and after using the
+// longest common subsequence of their lines, after setting aside the lines
+// they start and end with in common
+func diffLines(before, after string) []diffLine {
+	a := splitLines(before)
+	b := splitLines(after)
+
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var lines []diffLine
+	for _, line := range a[:prefix] {
+		lines = append(lines, diffLine{diffEqual, line})
+	}
+	lines = append(lines, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		lines = append(lines, diffLine{diffEqual, line})
+	}
+	return lines
+}
+
+// diffMiddle diffs the lines between the common prefix and suffix
+func diffMiddle(a, b []string) []diffLine {
+	var lines []diffLine
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			lines = append(lines, diffLine{diffRemoved, line})
+		}
+		for _, line := range b {
+			lines = append(lines, diffLine{diffAdded, line})
+		}
+		return lines
+	}
+
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, diffLine{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, diffLine{diffRemoved, a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{diffAdded, b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, diffLine{diffRemoved, a[i]})
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, diffLine{diffAdded, b[j]})
+	}
+	return lines
+}
+
+// renderDiff formats changed lines with a little surrounding context,
+// returning an empty string when there are no changes
+func renderDiff(lines []diffLine) string {
+	show := make([]bool, len(lines))
+	changed := false
+	for n, line := range lines {
+		if line.op == diffEqual {
+			continue
+		}
+		changed = true
+		for c := max(0, n-diffContext); c <= min(len(lines)-1, n+diffContext); c++ {
+			show[c] = true
+		}
+	}
+	if !changed {
+		return ""
+	}
+
+	var out []string
+	for n, line := range lines {
+		if !show[n] {
+			if n > 0 && show[n-1] {
+				out = append(out, diffHunkStyle.Render("..."))
+			}
+			continue
+		}
+		switch line.op {
+		case diffAdded:
+			out = append(out, diffAddedStyle.Render("+ "+line.text))
+		case diffRemoved:
+			out = append(out, diffRemovedStyle.Render("- "+line.text))
+		default:
+			out = append(out, "  "+line.text)
+		}
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDiffLines(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          []diffLine
+	}{
+		{"both empty", "", "", nil},
+		{"empty before", "", "a\nb\n", []diffLine{{diffAdded, "a"}, {diffAdded, "b"}}},
+		{"empty after", "a\n", "", []diffLine{{diffRemoved, "a"}}},
+		{"identical", "a\nb\n", "a\nb\n", []diffLine{{diffEqual, "a"}, {diffEqual, "b"}}},
+		{"changed line", "a\nb\nc\n", "a\nB\nc\n", []diffLine{{diffEqual, "a"}, {diffRemoved, "b"}, {diffAdded, "B"}, {diffEqual, "c"}}},
+		{"trailing newline added", "a", "a\n", []diffLine{{diffEqual, "a"}, {diffRemoved, noNewline}}},
+		{"trailing newline removed", "a\n", "a", []diffLine{{diffEqual, "a"}, {diffAdded, noNewline}}},
+		{"blank line added", "a\n", "a\n\n", []diffLine{{diffEqual, "a"}, {diffAdded, ""}}},
+	}
+	for _, test := range tests {
+		if got := diffLines(test.before, test.after); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: diffLines = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestRenderDiff(t *testing.T) {
+	numbered := func(from, to int) string {
+		var lines []string
+		for n := from; n <= to; n++ {
+			lines = append(lines, strings.Repeat("x", n))
+		}
+		return strings.Join(lines, "\n") + "\n"
+	}
+
+	tests := []struct {
+		name          string
+		before, after string
+		want          []string
+	}{
+		{"both empty", "", "", nil},
+		{"identical", "a\nb\n", "a\nb\n", nil},
+		{"empty before", "", "a\n", []string{"+ a"}},
+		{"trailing newline", "a", "a\n", []string{"  a", "- " + noNewline}},
+		{
+			"context around a change",
+			numbered(1, 9),
+			strings.Replace(numbered(1, 9), "xxxxx\n", "five\n", 1),
+			[]string{"  xxx", "  xxxx", "- xxxxx", "+ five", "  xxxxxx", "  xxxxxxx", "..."},
+		},
+	}
+	for _, test := range tests {
+		got := renderDiff(diffLines(test.before, test.after))
+		want := strings.Join(test.want, "\n")
+		if got != want {
+			t.Errorf("%s: renderDiff =\n%s\nwant\n%s", test.name, got, want)
+		}
+	}
+}
+
+func TestDiffLinesLargeInput(t *testing.T) {
+	var before, after strings.Builder
+	before.WriteString("same\n")
+	after.WriteString("same\n")
+	const n = 3000
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&before, "old %d\n", i)
+		fmt.Fprintf(&after, "new %d\n", i)
+	}
+	before.WriteString("end\n")
+	after.WriteString("end\n")
+
+	lines := diffLines(before.String(), after.String())
+	if len(lines) != 2*n+2 {
+		t.Fatalf("got %d lines, want %d", len(lines), 2*n+2)
+	}
+	if lines[0] != (diffLine{diffEqual, "same"}) || lines[len(lines)-1] != (diffLine{diffEqual, "end"}) {
+		t.Errorf("expected the common first and last lines to be kept, got %v and %v", lines[0], lines[len(lines)-1])
+	}
+	if lines[1] != (diffLine{diffRemoved, "old 0"}) || lines[n+1] != (diffLine{diffAdded, "new 0"}) {
+		t.Errorf("expected the rest to be replaced wholesale, got %v and %v", lines[1], lines[n+1])
+	}
+}
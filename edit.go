@@ -0,0 +1,236 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+// runEdit reopens a previously written output catalog in the TUI with its
+// capabilities preselected, saving changes back to the same file
+func runEdit(args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: controls-canvas edit [flags] <catalog.yaml>")
+		fs.PrintDefaults()
+	}
+	catalogId := fs.String("catalog", "", "ID of the catalog the file was built from (default: matched by reference-id)")
+	backup := fs.Bool("backup", false, "Keep the previous output at <out>.bak when replacing it")
+	embed := fs.Bool("embed", false, "Embed the selected capabilities, threats and controls (default: as the file was written)")
+	var sources sourceOptions
+	sources.register(fs)
+	cacheSettings.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cacheSettings.validate(); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	path := fs.Arg(0)
+	embedSet := false
+	fs.Visit(func(f *flag.Flag) {
+		embedSet = embedSet || f.Name == "embed"
+	})
+
+	format, err := editFormat(path)
+	if err != nil {
+		return err
+	}
+	existing, err := readOutputCatalog(path)
+	if err != nil {
+		return err
+	}
+
+	catalogs, problems, err := sources.catalogs()
+	if err != nil {
+		return err
+	}
+	source, err := findEditSource(catalogs, existing, *catalogId)
+	if err != nil {
+		return err
+	}
+
+	m := newCatalogInputModel(catalogs, problems)
+	choices, loadProblems, err := loadChoices(source)
+	if err != nil {
+		return err
+	}
+
+	catalogMetadata = withDefaultId(existing.Metadata)
+	for _, category := range existing.Metadata.ApplicabilityCategories {
+		if level, ok := findApplicabilityCategory(category.Id); ok {
+			applicabilityLevel = level
+		}
+	}
+	catalogMetadata.LastModified = time.Now().Format(dateFormat)
+	embedContent = len(existing.Capabilities) > 0
+	if embedSet {
+		embedContent = *embed
+	}
+	outputSettings.format = format
+	capabilityIds, threatIds, controlIds := outputIdentifiers(existing)
+	missing := selectItems(choices, capabilityIds)
+	restoreExclusions(threatIds, controlIds)
+
+	m.selected = source
+	m.setLoadProblems(loadProblems)
+	m.outputPath = path
+	m.backup = *backup
+	m.list.SetItems(choices)
+	m.list.Title = titleText + ": " + catalogMetadata.Title
+	m.state = "selecting"
+	if catalogMetadata.Title == "" {
+		m.list.Title = titleText
+	}
+	m.checkMetadata()
+	var cmd tea.Cmd
+	if len(missing) > 0 {
+		cmd = m.list.NewStatusMessage(errorMessageStyle("Not in catalog: " + strings.Join(missing, ", ")))
+	}
+	currentModel = m
+
+	if _, err := tea.NewProgram(editModel{model: m, init: cmd}, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("running model for catalog edit: %w", err)
+	}
+	return nil
+}
+
+// editModel wraps model to issue a command when the program starts
+type editModel struct {
+	model
+	init tea.Cmd
+}
+
+func (m editModel) Init() tea.Cmd {
+	return m.init
+}
+
+// readOutputCatalog reads a Layer 2 catalog previously written by this
+// tool. JSON is read as YAML, which it is a subset of.
+func readOutputCatalog(path string) (layer2.Catalog, error) {
+	var existing layer2.Catalog
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return existing, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, &existing); err != nil {
+		return existing, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if existing.Metadata.Id == "" && len(existing.SharedCapabilities) == 0 && len(existing.Capabilities) == 0 {
+		return existing, fmt.Errorf("%s is not a Layer 2 catalog", path)
+	}
+	return existing, nil
+}
+
+// editFormat picks the output format an edited file is saved back in from
+// its extension. Only Layer 2 catalogs can be edited, so the extensions of
+// the other formats are rejected rather than overwritten with one.
+func editFormat(path string) (string, error) {
+	for _, format := range outputFormats {
+		switch format.id {
+		case "layer2", "json", "oscal":
+			continue
+		}
+		if strings.HasSuffix(path, format.extension) {
+			return "", fmt.Errorf("%s looks like a %s; only Layer 2 catalogs can be edited", path, format.title)
+		}
+	}
+	switch filepath.Ext(path) {
+	case ".yaml", ".yml":
+		return "layer2", nil
+	case ".json":
+		return "json", nil
+	}
+	return "", fmt.Errorf("%s is not a YAML or JSON file; only Layer 2 catalogs can be edited", path)
+}
+
+// findEditSource picks the catalog an existing output was built from: the
+// one named by id, or else the one whose reference-id its shared
+// capabilities or mapping references use
+func findEditSource(catalogs []catalogItem, existing layer2.Catalog, id string) (catalogItem, error) {
+	if id != "" {
+		source, ok := findCatalog(catalogs, id)
+		if !ok {
+			return catalogItem{}, fmt.Errorf("unknown catalog %q", id)
+		}
+		return source, nil
+	}
+
+	var referenceIds []string
+	for _, mapping := range existing.SharedCapabilities {
+		referenceIds = append(referenceIds, mapping.ReferenceId)
+	}
+	for _, reference := range existing.Metadata.MappingReferences {
+		referenceIds = append(referenceIds, reference.Id)
+	}
+	for _, referenceId := range referenceIds {
+		for _, c := range catalogs {
+			if c.referenceId != "" && c.referenceId == referenceId {
+				return c, nil
+			}
+		}
+	}
+	return catalogItem{}, fmt.Errorf("could not determine which catalog the file was built from; use --catalog")
+}
+
+// outputIdentifiers lists the capabilities, threats and controls an
+// existing output catalog includes, whether shared or embedded
+func outputIdentifiers(existing layer2.Catalog) (capabilities, threats, controls []string) {
+	capabilities = sharedIdentifiers(existing.SharedCapabilities, catalogReferenceId)
+	for _, capability := range existing.Capabilities {
+		capabilities = append(capabilities, capability.Id)
+	}
+	threats = sharedIdentifiers(existing.SharedThreats, catalogReferenceId)
+	for _, threat := range existing.Threats {
+		threats = append(threats, threat.Id)
+	}
+	controls = sharedIdentifiers(existing.SharedControls, catalogReferenceId)
+	for _, family := range existing.ControlFamilies {
+		for _, control := range family.Controls {
+			controls = append(controls, control.Id)
+		}
+	}
+	return capabilities, threats, controls
+}
+
+// sharedIdentifiers returns the identifiers of mappings using referenceId
+func sharedIdentifiers(mappings []layer2.Mapping, referenceId string) (ids []string) {
+	for _, mapping := range mappings {
+		if mapping.ReferenceId == referenceId {
+			ids = append(ids, mapping.Identifiers...)
+		}
+	}
+	return ids
+}
+
+// selectItems marks the listed capabilities as selected, returning the IDs
+// that aren't among the choices
+func selectItems(choices []list.Item, ids []string) (missing []string) {
+	byId := make(map[string]item)
+	for _, choice := range choices {
+		if i, ok := choice.(item); ok {
+			byId[i.id] = i
+		}
+	}
+	for _, id := range ids {
+		i, ok := byId[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		selectedCapabilities[id] = i
+	}
+	return missing
+}
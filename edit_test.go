@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestEditFormat(t *testing.T) {
+	tests := map[string]string{
+		"output.yaml":           "layer2",
+		"output.yml":            "layer2",
+		"output.json":           "json",
+		"output.policy.yaml":    "",
+		"output.crosswalk.yaml": "",
+		"output.md":             "",
+		"output.csv":            "",
+		"output":                "",
+	}
+	for path, want := range tests {
+		got, err := editFormat(path)
+		if want == "" {
+			if err == nil {
+				t.Errorf("editFormat(%q) = %q, want an error", path, got)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Errorf("editFormat(%q) = %q, %v, want %q", path, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+// evaluationStubsPackage is the package and directory name of the Go stubs
+// written beside an evaluation plan
+const evaluationStubsPackage = "evaluations"
+
+var identifierPattern = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// evaluationPlan is a Layer 4 evaluation plan: the assessment requirements
+// of the selected controls, each waiting for the steps that will test it
+type evaluationPlan struct {
+	Metadata      layer2.Metadata     `yaml:"metadata"`
+	Catalog       catalogReference    `yaml:"catalog"`
+	Applicability string              `yaml:"applicability,omitempty"`
+	Evaluations   []controlEvaluation `yaml:"evaluations"`
+}
+
+type controlEvaluation struct {
+	ControlId   string              `yaml:"control-id"`
+	Title       string              `yaml:"title"`
+	Assessments []plannedAssessment `yaml:"assessments"`
+}
+
+type plannedAssessment struct {
+	RequirementId string   `yaml:"requirement-id"`
+	Text          string   `yaml:"text"`
+	Applicability []string `yaml:"applicability"`
+	Plugin        string   `yaml:"plugin"`
+	Steps         []string `yaml:"steps"`
+}
+
+// generateEvaluationPlan lists the applicable assessment requirements of
+// the selected controls. Each gets a step named after the Go stub that
+// would test it, and an empty plugin to fill in.
+func generateEvaluationPlan() evaluationPlan {
+	plan := evaluationPlan{
+		Metadata:      catalogMetadata,
+		Catalog:       sourceCatalogReference(),
+		Applicability: applicabilityLevel.Id,
+		Evaluations:   []controlEvaluation{},
+	}
+	plan.Metadata.ApplicabilityCategories = nil
+	plan.Metadata.MappingReferences = nil
+
+	for _, control := range selectedControls() {
+		evaluation := controlEvaluation{
+			ControlId:   control.Data.Id,
+			Title:       singleLine(control.Data.Title),
+			Assessments: []plannedAssessment{},
+		}
+		for _, requirement := range control.Data.AssessmentRequirements {
+			if !requirementApplies(requirement) {
+				continue
+			}
+			evaluation.Assessments = append(evaluation.Assessments, plannedAssessment{
+				RequirementId: requirement.Id,
+				Text:          singleLine(requirement.Text),
+				Applicability: requirement.Applicability,
+				Steps:         []string{goIdentifier(requirement.Id, "Step_")},
+			})
+		}
+		plan.Evaluations = append(plan.Evaluations, evaluation)
+	}
+	return plan
+}
+
+// renderEvaluationFiles renders the evaluation plan, followed by a Go stub
+// per control when evaluationStubs is set. Stubs that already exist are
+// left alone, as they may have been implemented since.
+func renderEvaluationFiles(path string) ([]outputFile, error) {
+	plan := generateEvaluationPlan()
+	data, err := yaml.Marshal(plan)
+	if err != nil {
+		return nil, err
+	}
+	files := []outputFile{{path: path, data: data}}
+	if !outputSettings.evaluationStubs {
+		return files, nil
+	}
+
+	if err := checkEvaluationIdentifiers(plan); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(filepath.Dir(path), evaluationStubsPackage)
+	for _, evaluation := range plan.Evaluations {
+		name := filepath.Join(dir, evaluationStubFilename(evaluation.ControlId))
+		if _, err := os.Stat(name); err == nil {
+			continue
+		}
+		stub, err := renderEvaluationStub(evaluation)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render stub for %s: %w", evaluation.ControlId, err)
+		}
+		files = append(files, outputFile{path: name, data: stub})
+	}
+	return files, nil
+}
+
+// renderEvaluationStub writes a Go file building the control's evaluation
+// with a step per assessment requirement, each reporting that it needs
+// review until implemented
+func renderEvaluationStub(evaluation controlEvaluation) ([]byte, error) {
+	var buf bytes.Buffer
+	err := evaluationStub.Execute(&buf, map[string]any{
+		"Package":    evaluationStubsPackage,
+		"Evaluation": evaluation,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return format.Source(buf.Bytes())
+}
+
+// goIdentifier turns an ID such as CCC.C01.TR01 into CCC_C01_TR01. IDs
+// not starting with a letter, such as 1.1, get prefix: Step_1_1.
+func goIdentifier(id, prefix string) string {
+	identifier := strings.Trim(identifierPattern.ReplaceAllString(id, "_"), "_")
+	if identifier == "" || !unicode.IsLetter(rune(identifier[0])) {
+		identifier = prefix + identifier
+	}
+	return identifier
+}
+
+// controlIdentifier names the function evaluating a control
+func controlIdentifier(id string) string {
+	return goIdentifier(id, "Control_")
+}
+
+// evaluationStubFilename names a control's stub file. The fixed suffix
+// keeps names like ccc_linux from being read as build constraints.
+func evaluationStubFilename(controlId string) string {
+	return strings.ToLower(controlIdentifier(controlId)) + "_evaluation.go"
+}
+
+// checkEvaluationIdentifiers fails when two controls would share a stub
+// file, or two functions in the stubs package would share a name, as
+// happens with IDs differing only in punctuation or case
+func checkEvaluationIdentifiers(plan evaluationPlan) error {
+	files := make(map[string]string)
+	functions := make(map[string]string)
+	claim := func(names map[string]string, name, id string) error {
+		if other, ok := names[name]; ok && other != id {
+			return fmt.Errorf("%s and %s would both be written as %s", other, id, name)
+		}
+		names[name] = id
+		return nil
+	}
+	for _, evaluation := range plan.Evaluations {
+		control := "control " + evaluation.ControlId
+		if err := claim(files, evaluationStubFilename(evaluation.ControlId), control); err != nil {
+			return err
+		}
+		if err := claim(functions, controlIdentifier(evaluation.ControlId), control); err != nil {
+			return err
+		}
+		for _, assessment := range evaluation.Assessments {
+			for _, step := range assessment.Steps {
+				if err := claim(functions, step, "requirement "+assessment.RequirementId); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+var evaluationStub = template.Must(template.New("stub").Funcs(template.FuncMap{
+	"ident": controlIdentifier,
+}).Parse(`// Code generated by controls-canvas for {{.Evaluation.ControlId}}. Implement each step below.
+
+package {{.Package}}
+
+import "github.com/revanite-io/sci/layer4"
+
+// {{ident .Evaluation.ControlId}} evaluates {{.Evaluation.ControlId}}: {{.Evaluation.Title}}
+func {{ident .Evaluation.ControlId}}() (evaluation *layer4.ControlEvaluation) {
+	evaluation = &layer4.ControlEvaluation{
+		Name:       {{printf "%q" .Evaluation.ControlId}},
+		Control_Id: {{printf "%q" .Evaluation.ControlId}},
+	}
+{{range .Evaluation.Assessments}}
+	evaluation.AddAssessment(
+		{{printf "%q" .RequirementId}},
+		{{printf "%q" .Text}},
+		{{printf "%#v" .Applicability}},
+		[]layer4.AssessmentStep{
+			{{range .Steps}}{{.}},
+			{{end}}
+		},
+	)
+{{end}}
+	return evaluation
+}
+{{range .Evaluation.Assessments}}{{$requirement := .}}{{range .Steps}}
+// {{.}} tests {{$requirement.RequirementId}}: {{$requirement.Text}}
+func {{.}}(payload interface{}, changes map[string]*layer4.Change) (result layer4.Result, message string) {
+	return layer4.NeedsReview, "Not implemented"
+}
+{{end}}{{end}}`))
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoIdentifier(t *testing.T) {
+	tests := []struct {
+		id, prefix, want string
+	}{
+		{"CCC.C01.TR01", "Step_", "CCC_C01_TR01"},
+		{"1.1.1", "Step_", "Step_1_1_1"},
+		{"1.1", "Control_", "Control_1_1"},
+		{"_x", "Step_", "x"},
+		{"...", "Step_", "Step_"},
+	}
+	for _, test := range tests {
+		if got := goIdentifier(test.id, test.prefix); got != test.want {
+			t.Errorf("goIdentifier(%q, %q) = %q, want %q", test.id, test.prefix, got, test.want)
+		}
+	}
+}
+
+func TestEvaluationStubFilename(t *testing.T) {
+	tests := map[string]string{
+		"CCC.C01":   "ccc_c01_evaluation.go",
+		"1.1":       "control_1_1_evaluation.go",
+		"CCC.linux": "ccc_linux_evaluation.go",
+	}
+	for id, want := range tests {
+		if got := evaluationStubFilename(id); got != want {
+			t.Errorf("evaluationStubFilename(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
+func TestCheckEvaluationIdentifiers(t *testing.T) {
+	plan := evaluationPlan{Evaluations: []controlEvaluation{
+		{ControlId: "CCC.C01"},
+		{ControlId: "ccc.c01"},
+	}}
+	if err := checkEvaluationIdentifiers(plan); err == nil {
+		t.Error("expected controls sharing a stub file to be rejected")
+	}
+
+	plan = evaluationPlan{Evaluations: []controlEvaluation{
+		{ControlId: "A", Assessments: []plannedAssessment{{RequirementId: "A.1", Steps: []string{"A_1"}}}},
+		{ControlId: "A.1"},
+	}}
+	if err := checkEvaluationIdentifiers(plan); err == nil {
+		t.Error("expected a step and a control sharing a function name to be rejected")
+	}
+}
+
+// TestEvaluationStubCompiles builds rendered stubs in a temporary module
+// requiring the same layer4 version as this one, so they are checked
+// against the API they will be used with
+func TestEvaluationStubCompiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Path}} {{.Version}}", "github.com/revanite-io/sci").Output()
+	if err != nil {
+		t.Fatalf("finding the sci version: %v", err)
+	}
+	sums, err := os.ReadFile("go.sum")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	goMod := "module stubtest\n\ngo 1.23\n\nrequire " + strings.TrimSpace(string(out)) + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), sums, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	evaluations := []controlEvaluation{
+		{
+			ControlId: "1.1",
+			Title:     "Numbered control",
+			Assessments: []plannedAssessment{{
+				RequirementId: "1.1.1",
+				Text:          `Text with "quotes"`,
+				Applicability: []string{"tlp_clear"},
+				Steps:         []string{goIdentifier("1.1.1", "Step_")},
+			}},
+		},
+		{
+			ControlId: "CCC.C01",
+			Title:     "Prevent Unencrypted Requests",
+			Assessments: []plannedAssessment{{
+				RequirementId: "CCC.C01.TR01",
+				Text:          "When a port is exposed, the service MUST encrypt traffic.",
+				Steps:         []string{goIdentifier("CCC.C01.TR01", "Step_")},
+			}},
+		},
+	}
+	for _, evaluation := range evaluations {
+		stub, err := renderEvaluationStub(evaluation)
+		if err != nil {
+			t.Fatalf("rendering stub for %s: %v", evaluation.ControlId, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, evaluationStubFilename(evaluation.ControlId)), stub, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	build := exec.Command("go", "build", "-mod=mod", ".")
+	build.Dir = dir
+	build.Env = append(os.Environ(), "GOPROXY=off", "GOWORK=off")
+	out, err = build.CombinedOutput()
+	if err != nil {
+		t.Fatalf("stubs don't compile: %v\n%s", err, strings.TrimSpace(string(out)))
+	}
+}
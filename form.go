@@ -1,8 +1,22 @@
 package main
 
 import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
 	"github.com/charmbracelet/bubbles/list"
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/revanite-io/sci/layer2"
+)
+
+const dateFormat = "2006-01-02"
+
+var (
+	idPattern     = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+	semverPattern = regexp.MustCompile(`^v?(0|[1-9]\d*)\.(0|[1-9]\d*)\.(0|[1-9]\d*)(-[0-9A-Za-z.-]+)?(\+[0-9A-Za-z.-]+)?$`)
 )
 
 type formField struct {
@@ -10,6 +24,7 @@ type formField struct {
 	value       string
 	description string
 	isEditing   bool
+	err         string
 }
 
 func (i formField) Title() string {
@@ -19,14 +34,20 @@ func (i formField) Title() string {
 	return i.key + ": " + i.value
 }
 
-func (i formField) Description() string { return i.description }
+func (i formField) Description() string {
+	if i.err != "" {
+		return errorMessageStyle(i.err)
+	}
+	return i.description
+}
+
 func (i formField) FilterValue() string { return i.key }
 
 func newFormFields() []list.Item {
 	return []list.Item{
 		formField{
 			key:         "id",
-			description: "A unique identifier for this catalog",
+			description: "A unique identifier for this catalog (default: derived from the title)",
 		},
 		formField{
 			key:         "title",
@@ -56,4 +77,196 @@ func getFormStyle() lipgloss.Style {
 		BorderLeft(true).
 		BorderRight(true).
 		BorderBottom(true)
-} 
\ No newline at end of file
+}
+
+// newMetadataForm builds the metadata form prefilled from metadata, with
+// last-modified defaulting to today
+func newMetadataForm(metadata layer2.Metadata) list.Model {
+	if metadata.LastModified == "" {
+		metadata.LastModified = time.Now().Format(dateFormat)
+	}
+	values := metadataValues(metadata)
+
+	fields := newFormFields()
+	for n, field := range fields {
+		f := field.(formField)
+		f.value = values[f.key]
+		f.isEditing = n == 0
+		fields[n] = f
+	}
+	return newFormList(fields)
+}
+
+// newFormList builds a form from fields, the first of which should be
+// editing
+func newFormList(fields []list.Item) list.Model {
+	d := newItemDelegate(newDelegateKeyMap())
+	form := list.New(fields, d, 0, 0)
+	form.SetShowTitle(false)
+	form.SetShowStatusBar(false)
+	form.SetShowPagination(false)
+	form.SetShowHelp(false)
+	form.SetFilteringEnabled(false)
+	return form
+}
+
+// updateForm handles a key press on the metadata form, returning true once
+// every field is valid and the form has been submitted
+func updateForm(form *list.Model, msg tea.KeyMsg) (submitted bool) {
+	index := form.Index()
+	field := form.SelectedItem().(formField)
+
+	switch msg.Type {
+	case tea.KeyTab, tea.KeyDown:
+		focusFormField(form, (index+1)%len(form.Items()))
+	case tea.KeyShiftTab, tea.KeyUp:
+		focusFormField(form, (index+len(form.Items())-1)%len(form.Items()))
+	case tea.KeyBackspace:
+		if len(field.value) > 0 {
+			field.value = field.value[:len(field.value)-1]
+			field.err = ""
+			form.SetItem(index, field)
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		field.value += string(msg.Runes)
+		field.err = ""
+		form.SetItem(index, field)
+	case tea.KeyEnter:
+		if index < len(form.Items())-1 {
+			focusFormField(form, index+1)
+			return false
+		}
+		return validateForm(form)
+	}
+	return false
+}
+
+// focusFormField moves editing to the field at index
+func focusFormField(form *list.Model, index int) {
+	for n, item := range form.Items() {
+		field := item.(formField)
+		field.isEditing = n == index
+		form.SetItem(n, field)
+	}
+	form.Select(index)
+}
+
+// validateForm checks every field, recording errors on the fields that
+// fail and focusing the first of them
+func validateForm(form *list.Model) bool {
+	firstInvalid := -1
+	for n, item := range form.Items() {
+		field := item.(formField)
+		field.err = ""
+		if err := validateFormField(field.key, field.value); err != nil {
+			field.err = err.Error()
+			if firstInvalid < 0 {
+				firstInvalid = n
+			}
+		}
+		form.SetItem(n, field)
+	}
+	if firstInvalid >= 0 {
+		focusFormField(form, firstInvalid)
+		return false
+	}
+	return true
+}
+
+// validateFormField reports why value isn't acceptable for the field key
+func validateFormField(key, value string) error {
+	switch key {
+	case "id":
+		if value != "" && !idPattern.MatchString(value) {
+			return fmt.Errorf("id must start with a letter or digit and contain only letters, digits, '.', '_' or '-'")
+		}
+	case "title":
+		if value == "" {
+			return fmt.Errorf("title is required")
+		}
+	case "version":
+		if value != "" && !semverPattern.MatchString(value) {
+			return fmt.Errorf("version must be a semantic version such as 1.0.0")
+		}
+	case "last-modified":
+		if _, err := time.Parse(dateFormat, value); err != nil {
+			return fmt.Errorf("last-modified must be a date in YYYY-MM-DD format")
+		}
+	case "mandated":
+		if !strings.EqualFold(value, "yes") && !strings.EqualFold(value, "no") {
+			return fmt.Errorf("mandated must be yes or no")
+		}
+	case "level":
+		if _, ok := findApplicabilityCategory(value); value != "" && !ok {
+			return fmt.Errorf("level must be one of the catalog's applicability categories")
+		}
+	}
+	return nil
+}
+
+// validateMetadata applies the form's validation to metadata supplied
+// some other way, such as command line flags
+func validateMetadata(metadata layer2.Metadata) error {
+	values := metadataValues(metadata)
+	for _, item := range newFormFields() {
+		key := item.(formField).key
+		if err := validateFormField(key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metadataValues maps form field keys to their values in metadata
+func metadataValues(metadata layer2.Metadata) map[string]string {
+	return map[string]string{
+		"id":            metadata.Id,
+		"title":         metadata.Title,
+		"description":   metadata.Description,
+		"version":       metadata.Version,
+		"last-modified": metadata.LastModified,
+	}
+}
+
+// metadataFromForm collects the form's values into catalog metadata
+func metadataFromForm(form list.Model) layer2.Metadata {
+	values := make(map[string]string)
+	for _, item := range form.Items() {
+		field := item.(formField)
+		values[field.key] = field.value
+	}
+	return withDefaultId(layer2.Metadata{
+		Id:           values["id"],
+		Title:        values["title"],
+		Description:  values["description"],
+		Version:      values["version"],
+		LastModified: values["last-modified"],
+	})
+}
+
+// withDefaultId fills in a missing id with a slug of the title, such as
+// "payments" for "Payments". Without a title it is left for validation to
+// report.
+func withDefaultId(metadata layer2.Metadata) layer2.Metadata {
+	if metadata.Id == "" && metadata.Title != "" {
+		metadata.Id = slugId(metadata.Title)
+	}
+	return metadata
+}
+
+// slugId turns a title into an id idPattern accepts
+func slugId(title string) string {
+	if slug := slugify(title); slug != "" {
+		return slug
+	}
+	return "catalog"
+}
+
+// slugify lowercases text and joins its runs of ASCII letters and digits
+// with hyphens
+func slugify(text string) string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	})
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// outputFile is one file written for the output catalog
+type outputFile struct {
+	path string
+	data []byte
+}
+
+// outputFormat is a way of writing the output catalog. render returns the
+// files to write for output at path, the first of them being path itself.
+type outputFormat struct {
+	id        string
+	title     string
+	extension string
+	render    func(path string) ([]outputFile, error)
+}
+
+var outputFormats = []outputFormat{
+	{id: "layer2", title: "Layer 2 catalog (YAML)", extension: ".yaml", render: renderLayer2Files},
+	{id: "json", title: "Layer 2 catalog (JSON)", extension: ".json", render: renderLayer2JsonFiles},
+	{id: "oscal", title: "OSCAL profile (JSON)", extension: ".json", render: renderOscalFiles},
+	{id: "markdown", title: "Markdown report", extension: ".md", render: renderMarkdownFiles},
+	{id: "html", title: "HTML report", extension: ".html", render: renderHtmlFiles},
+	{id: "csv", title: "CSV matrix", extension: ".csv", render: renderCsvFiles},
+	{id: "policy", title: "Layer 3 policy (YAML)", extension: ".policy.yaml", render: renderPolicyFiles},
+	{id: "evaluation", title: "Layer 4 evaluation plan (YAML)", extension: ".evaluation.yaml", render: renderEvaluationFiles},
+	{id: "crosswalk", title: "Framework crosswalk (YAML)", extension: ".crosswalk.yaml", render: renderCrosswalkFiles},
+}
+
+// outputOptions controls how the output catalog is written
+type outputOptions struct {
+	format          string
+	evaluationStubs bool
+}
+
+var outputSettings = outputOptions{format: "layer2"}
+
+func (o *outputOptions) register(fs *flag.FlagSet) {
+	var ids []string
+	for _, format := range outputFormats {
+		ids = append(ids, format.id)
+	}
+	fs.StringVar(&o.format, "format", outputFormats[0].id, "Output format: "+strings.Join(ids, ", ")+
+		" (oscal also writes the OSCAL catalog its profile imports, as <out>-catalog.json)")
+	fs.BoolVar(&o.evaluationStubs, "evaluation-stubs", false, "With --format evaluation, also write Go evaluation stubs to an \""+evaluationStubsPackage+"\" directory beside the plan")
+}
+
+func (o *outputOptions) validate() error {
+	if _, ok := findOutputFormat(o.format); !ok {
+		return fmt.Errorf("unknown output format %q", o.format)
+	}
+	return nil
+}
+
+// outputFormat returns the chosen format, falling back to the first one
+func (o *outputOptions) outputFormat() outputFormat {
+	if format, ok := findOutputFormat(o.format); ok {
+		return format
+	}
+	return outputFormats[0]
+}
+
+// defaultPath is where output goes when no path is given
+func (o *outputOptions) defaultPath() string {
+	return "output" + o.outputFormat().extension
+}
+
+// cycleFormat switches to the next output format, returning path with its
+// extension changed to match if it had the previous format's extension
+func (o *outputOptions) cycleFormat(path string) string {
+	previous := o.outputFormat()
+	for n, format := range outputFormats {
+		if format.id == previous.id {
+			o.format = outputFormats[(n+1)%len(outputFormats)].id
+		}
+	}
+	if strings.HasSuffix(path, previous.extension) {
+		return strings.TrimSuffix(path, previous.extension) + o.outputFormat().extension
+	}
+	return path
+}
+
+// renderPreview renders the output catalog for the live preview beside the
+// capability list: as JSON when that is the chosen format, otherwise as
+// the Layer 2 YAML the other formats are derived from
+func renderPreview() ([]byte, error) {
+	if outputSettings.format == "json" {
+		return renderOutputCatalogJson()
+	}
+	return renderOutputCatalog()
+}
+
+// findOutputFormat returns the format with the given ID
+func findOutputFormat(id string) (outputFormat, bool) {
+	for _, format := range outputFormats {
+		if format.id == id {
+			return format, true
+		}
+	}
+	return outputFormat{}, false
+}
+
+// renderOutputFiles renders the output catalog at path in the chosen format.
+// Every write goes through here, so invalid metadata, which the published
+// schema would reject, is never written.
+func renderOutputFiles(path string) ([]outputFile, error) {
+	if err := validateMetadata(catalogMetadata); err != nil {
+		return nil, fmt.Errorf("invalid catalog metadata: %w", err)
+	}
+	return outputSettings.outputFormat().render(path)
+}
+
+// companionPath returns the path of a file written alongside path, named
+// after it with suffix
+func companionPath(path, suffix string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + suffix
+}
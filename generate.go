@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+// runGenerate builds an output catalog from command line flags without
+// starting the TUI, so catalogs can be regenerated in CI
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	name := fs.String("name", "", "Title of the output catalog")
+	id := fs.String("id", "", "Identifier of the output catalog (default: derived from --name)")
+	description := fs.String("description", "", "Description of the output catalog")
+	version := fs.String("version", "", "Semantic version of the output catalog")
+	lastModified := fs.String("last-modified", time.Now().Format(dateFormat), "Date the output catalog was last modified (YYYY-MM-DD)")
+	out := fs.String("out", "", "Path to write the output catalog to (default \"output\" with the format's extension)")
+	backup := fs.Bool("backup", false, "Keep the previous output at <out>.bak when replacing it")
+	fs.BoolVar(&embedContent, "embed", false, "Embed the selected capabilities, threats and controls instead of referencing them")
+	var selection selectionOptions
+	selection.register(fs)
+	var sources sourceOptions
+	sources.register(fs)
+	cacheSettings.register(fs)
+	outputSettings.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cacheSettings.validate(); err != nil {
+		return err
+	}
+	if err := outputSettings.validate(); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = outputSettings.defaultPath()
+	}
+
+	catalogMetadata = withDefaultId(layer2.Metadata{
+		Id:           *id,
+		Title:        *name,
+		Description:  *description,
+		Version:      *version,
+		LastModified: *lastModified,
+	})
+	if err := validateMetadata(catalogMetadata); err != nil {
+		return err
+	}
+	if err := selection.validate(); err != nil {
+		return err
+	}
+
+	explanation, err := selection.apply(sources)
+	if err != nil {
+		return err
+	}
+	for _, line := range explanation {
+		fmt.Println(line)
+	}
+	if outputSettings.format == "policy" {
+		if err := restorePolicies(*out); err != nil {
+			return err
+		}
+	}
+
+	files, err := renderOutputFiles(*out)
+	if err != nil {
+		return err
+	}
+	for n, file := range files {
+		if existing, err := os.ReadFile(file.path); err == nil && bytes.Equal(existing, file.data) {
+			fmt.Printf("%s is up to date\n", file.path)
+			continue
+		}
+		if err := writeFileAtomic(file.path, file.data, *backup); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+		if n == 0 {
+			fmt.Printf("Wrote %d capabilities to %s\n", len(selectedCapabilities), file.path)
+		} else {
+			fmt.Printf("Wrote %s\n", file.path)
+		}
+	}
+	if filtered := filteredRequirements(); len(filtered) > 0 {
+		fmt.Printf("Left out %d assessment requirements not applicable at %s\n", len(filtered), applicabilityLevel.Id)
+	}
+	return nil
+}
+
+// selectCapabilities marks the capabilities with the given IDs as selected,
+// failing if any of them are not present in the loaded data
+func selectCapabilities(data []availableCapability, ids []string) error {
+	byId := make(map[string]availableCapability)
+	for _, capability := range data {
+		byId[capability.Data.Id] = capability
+	}
+
+	var missing []string
+	for _, id := range ids {
+		capability, ok := byId[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		selectedCapabilities[id] = item{
+			id:         capability.Data.Id,
+			title:      capability.Data.Title,
+			capability: capability,
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("unknown capabilities: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// splitList splits a comma-separated flag value, dropping empty entries
+func splitList(value string) (out []string) {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+// TestGenerateWithoutId runs the documented headless invocation, which
+// gives a name but no id, against a local copy of the ccc catalog
+func TestGenerateWithoutId(t *testing.T) {
+	useTempCache(t)
+	registry, err := filepath.Abs("testdata/ccc/catalogs.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	selectedCapabilities = map[string]item{}
+	excludedThreats = map[string]bool{}
+	excludedControls = map[string]bool{}
+
+	args := []string{"--catalog", "ccc", "--name", "Payments", "--capabilities", "CCC.F02,CCC.F06", "--out", "policy.yaml"}
+	if err := runGenerate(append(args, "--config", registry)); err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+
+	data, err := os.ReadFile("policy.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var output layer2.Catalog
+	if err := yaml.Unmarshal(data, &output); err != nil {
+		t.Fatal(err)
+	}
+	if output.Metadata.Id != "payments" || output.Metadata.Title != "Payments" {
+		t.Errorf("got metadata id %q and title %q", output.Metadata.Id, output.Metadata.Title)
+	}
+	if len(output.SharedCapabilities) != 1 || len(output.SharedCapabilities[0].Identifiers) != 2 {
+		t.Errorf("expected both capabilities to be shared, got %+v", output.SharedCapabilities)
+	}
+}
+
+func TestSlugId(t *testing.T) {
+	tests := map[string]string{
+		"Payments":              "payments",
+		"Payments Platform 2.0": "payments-platform-2-0",
+		"  Über -- Cloud ":      "ber-cloud",
+		"!!!":                   "catalog",
+	}
+	for title, want := range tests {
+		got := slugId(title)
+		if got != want {
+			t.Errorf("slugId(%q) = %q, want %q", title, got, want)
+		}
+		if !idPattern.MatchString(got) {
+			t.Errorf("slugId(%q) = %q doesn't match the id pattern", title, got)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// guideline identifies a requirement of an external framework, such as
+// AC-3 of NIST-800-53. An empty referenceId matches any framework.
+type guideline struct {
+	referenceId string
+	id          string
+}
+
+func (g guideline) String() string {
+	if g.referenceId == "" {
+		return g.id
+	}
+	return g.referenceId + " " + g.id
+}
+
+// guidelineInclusion is why a capability is needed to meet a guideline:
+// the control mapping to the guideline mitigates a threat the capability
+// faces
+type guidelineInclusion struct {
+	guideline  guideline
+	capability string
+	threat     string
+	control    availableControl
+}
+
+// parseGuidelines reads guidelines written as REFERENCE:ID, or as a bare ID
+// to look it up in every framework
+func parseGuidelines(values []string) (guidelines []guideline) {
+	for _, value := range values {
+		referenceId, id, ok := strings.Cut(value, ":")
+		if !ok {
+			referenceId, id = "", value
+		}
+		guidelines = append(guidelines, guideline{
+			referenceId: strings.TrimSpace(referenceId),
+			id:          strings.TrimSpace(id),
+		})
+	}
+	return guidelines
+}
+
+// resolveGuidelines follows each guideline back through the controls
+// mapping to it and the threats those controls mitigate to the
+// capabilities facing them. Guidelines no capability leads to are
+// returned as unresolved.
+func resolveGuidelines(guidelines []guideline) (inclusions []guidelineInclusion, unresolved []guideline) {
+	for _, g := range guidelines {
+		found := false
+		for _, capability := range catalogContents {
+			for _, threat := range capability.Threats {
+				for _, control := range threat.Controls {
+					matched, ok := controlMapsTo(control, g)
+					if !ok {
+						continue
+					}
+					found = true
+					inclusions = append(inclusions, guidelineInclusion{
+						guideline:  matched,
+						capability: capability.Data.Id,
+						threat:     threat.Data.Id,
+						control:    control,
+					})
+				}
+			}
+		}
+		if !found {
+			unresolved = append(unresolved, g)
+		}
+	}
+	sort.SliceStable(inclusions, func(i, j int) bool {
+		return inclusions[i].capability < inclusions[j].capability
+	})
+	return inclusions, unresolved
+}
+
+// controlMapsTo reports whether a control's guideline mappings include g,
+// returning the guideline as the control names it
+func controlMapsTo(control availableControl, g guideline) (guideline, bool) {
+	for _, mapping := range control.Data.GuidelineMappings {
+		if g.referenceId != "" && !strings.EqualFold(mapping.ReferenceId, g.referenceId) {
+			continue
+		}
+		for _, id := range mapping.Identifiers {
+			if id = strings.TrimSpace(id); strings.EqualFold(id, g.id) {
+				return guideline{referenceId: mapping.ReferenceId, id: id}, true
+			}
+		}
+	}
+	return guideline{}, false
+}
+
+// includeGuidelineInclusions selects the capabilities the inclusions lead
+// to, taking them from items when present, and includes the threats and
+// controls connecting them to the guidelines again if they were excluded
+func includeGuidelineInclusions(inclusions []guidelineInclusion, items []list.Item) {
+	var capabilities []string
+	for _, inclusion := range inclusions {
+		capabilities = appendIfMissing(capabilities, inclusion.capability)
+		delete(excludedThreats, inclusion.threat)
+		delete(excludedControls, inclusion.control.Data.Id)
+	}
+	selectCapabilityIds(capabilities, items)
+}
+
+// explainGuidelineInclusions describes, per capability, which guidelines
+// made it needed and how, followed by the guidelines nothing leads to
+func explainGuidelineInclusions(inclusions []guidelineInclusion, unresolved []guideline) (lines []string) {
+	previous := ""
+	for _, inclusion := range inclusions {
+		if inclusion.capability != previous {
+			lines = append(lines, "Including "+inclusion.capability)
+			previous = inclusion.capability
+		}
+		line := fmt.Sprintf("  %s: %s mitigates %s", inclusion.guideline, inclusion.control.Data.Id, inclusion.threat)
+		if !controlApplies(inclusion.control.Data) {
+			line += " (not applicable at " + applicabilityLevel.Id + ")"
+		}
+		lines = append(lines, line)
+	}
+	for _, g := range unresolved {
+		lines = append(lines, "No capability leads to "+g.String())
+	}
+	return lines
+}
+
+// guidelineItem is an external guideline in the list to select
+// capabilities by
+type guidelineItem struct {
+	guideline guideline
+	controls  []string
+	picked    map[guideline]bool
+}
+
+func (i guidelineItem) Title() string {
+	return checkbox(i.picked[i.guideline]) + " " + i.guideline.String()
+}
+
+func (i guidelineItem) Description() string {
+	return "Mapped by " + strings.Join(i.controls, ", ")
+}
+
+func (i guidelineItem) FilterValue() string { return i.guideline.String() }
+
+// guidelineItems lists every guideline the catalog's controls map to,
+// grouped by framework
+func guidelineItems(picked map[guideline]bool) (items []list.Item) {
+	for _, framework := range buildCrosswalk().Frameworks {
+		for _, g := range framework.Guidelines {
+			controls := append(g.Controls, g.OtherControls...)
+			sort.Strings(controls)
+			items = append(items, guidelineItem{
+				guideline: guideline{referenceId: framework.ReferenceId, id: g.Id},
+				controls:  controls,
+				picked:    picked,
+			})
+		}
+	}
+	return items
+}
+
+// pickedGuidelines returns the picked guidelines in a stable order
+func pickedGuidelines(picked map[guideline]bool) (guidelines []guideline) {
+	for g := range picked {
+		guidelines = append(guidelines, g)
+	}
+	sort.Slice(guidelines, func(i, j int) bool {
+		return guidelines[i].String() < guidelines[j].String()
+	})
+	return guidelines
+}
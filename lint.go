@@ -0,0 +1,246 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+// lintFinding is a problem with a loaded catalog that would otherwise go
+// unnoticed, as loadData skips what it can't link up
+type lintFinding struct {
+	Catalog  string `json:"catalog,omitempty"`
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	Id       string `json:"id,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (f lintFinding) String() string {
+	text := f.Severity + ": " + f.Kind
+	if f.Id != "" {
+		text += " " + f.Id
+	}
+	return text + ": " + f.Message
+}
+
+// lintCatalog checks a catalog for entries loadData skips, references it
+// can't resolve, duplicate IDs and controls without requirements.
+// referenceId is the reference-id the catalog uses for its own entries.
+func lintCatalog(c layer2.Catalog, referenceId string) (findings []lintFinding) {
+	add := func(severity, kind, id, format string, args ...any) {
+		findings = append(findings, lintFinding{
+			Severity: severity,
+			Kind:     kind,
+			Id:       id,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	capabilityIds := make(map[string]bool)
+	for n, capability := range c.Capabilities {
+		switch {
+		case capability.Id == "":
+			add("warning", "skipped", "", "capability #%d has no id", n+1)
+			continue
+		case capability.Title == "":
+			add("warning", "skipped", capability.Id, "capability has no title")
+			continue
+		}
+		if capabilityIds[capability.Id] {
+			add("error", "duplicate-id", capability.Id, "capability is defined more than once")
+		}
+		capabilityIds[capability.Id] = true
+	}
+
+	threatIds := make(map[string]bool)
+	for n, threat := range c.Threats {
+		if threat.Id == "" {
+			add("warning", "skipped", "", "threat #%d has no id", n+1)
+			continue
+		}
+		if len(threat.Capabilities) == 0 {
+			add("warning", "unmapped", threat.Id, "threat maps to no capabilities")
+		}
+		if threat.Title == "" {
+			add("warning", "skipped", threat.Id, "threat has no title")
+			continue
+		}
+		if threatIds[threat.Id] {
+			add("error", "duplicate-id", threat.Id, "threat is defined more than once")
+		}
+		threatIds[threat.Id] = true
+		for _, mapping := range threat.Capabilities {
+			if mapping.ReferenceId != referenceId {
+				continue
+			}
+			for _, id := range mapping.Identifiers {
+				if !capabilityIds[id] {
+					add("error", "dangling-reference", threat.Id, "threat maps to unknown capability %s", id)
+				}
+			}
+		}
+	}
+
+	families := make(map[string][]string)
+	definitions := make(map[string]int)
+	for n, family := range c.ControlFamilies {
+		name := family.Title
+		if name == "" {
+			name = fmt.Sprintf("family #%d", n+1)
+		}
+		for m, control := range family.Controls {
+			if control.Id == "" {
+				add("warning", "skipped", "", "control #%d in %s has no id", m+1, name)
+				continue
+			}
+			families[control.Id] = appendIfMissing(families[control.Id], name)
+			definitions[control.Id]++
+			if len(control.AssessmentRequirements) == 0 {
+				add("warning", "no-requirements", control.Id, "control has no assessment requirements")
+			}
+			for _, mapping := range control.ThreatMappings {
+				if mapping.ReferenceId != referenceId {
+					continue
+				}
+				for _, id := range mapping.Identifiers {
+					if !threatIds[id] {
+						add("error", "dangling-reference", control.Id, "control maps to unknown threat %s", id)
+					}
+				}
+			}
+		}
+	}
+	for _, family := range c.ControlFamilies {
+		for _, control := range family.Controls {
+			if count := definitions[control.Id]; count > 1 {
+				add("error", "duplicate-id", control.Id, "control is defined %d times, in %s", count, strings.Join(families[control.Id], ", "))
+				delete(definitions, control.Id)
+			}
+		}
+	}
+	return findings
+}
+
+// lintLoadedCatalog lints the catalog loaded by loadData
+func lintLoadedCatalog() []lintFinding {
+	return lintCatalog(catalog, catalogReferenceId)
+}
+
+// countFindings counts the findings of each severity
+func countFindings(findings []lintFinding) (errors, warnings int) {
+	for _, finding := range findings {
+		if finding.Severity == "error" {
+			errors++
+		} else {
+			warnings++
+		}
+	}
+	return errors, warnings
+}
+
+// findingsSummary describes how many errors and warnings were found, for
+// the line beneath the capability list
+func findingsSummary(findings []lintFinding) string {
+	errors, warnings := countFindings(findings)
+	var counts []string
+	if errors > 0 {
+		counts = append(counts, fmt.Sprintf("%d catalog errors", errors))
+	}
+	if warnings > 0 {
+		counts = append(counts, fmt.Sprintf("%d catalog warnings", warnings))
+	}
+	return strings.Join(counts, ", ")
+}
+
+// renderWarnings describes the findings for the warnings screen
+func renderWarnings(findings []lintFinding) string {
+	if len(findings) == 0 {
+		return "No problems found in the catalog."
+	}
+	var lines []string
+	for _, finding := range findings {
+		lines = append(lines, finding.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// runLint lints the chosen catalog, or every catalog in the registry,
+// failing when any errors are found so it can gate CI
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	catalogId := fs.String("catalog", "", "ID of the catalog to lint (default all catalogs)")
+	asJson := fs.Bool("json", false, "Print the findings as JSON")
+	strict := fs.Bool("strict", false, "Fail on warnings as well as errors")
+	var sources sourceOptions
+	sources.register(fs)
+	cacheSettings.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cacheSettings.validate(); err != nil {
+		return err
+	}
+
+	catalogs, problems, err := sources.catalogs()
+	if err != nil {
+		return err
+	}
+	findings := []lintFinding{}
+	if *catalogId != "" {
+		source, ok := findCatalog(catalogs, *catalogId)
+		if !ok {
+			return fmt.Errorf("unknown catalog %q", *catalogId)
+		}
+		catalogs = []catalogItem{source}
+	} else {
+		for _, problem := range problems {
+			findings = append(findings, lintFinding{Severity: "warning", Kind: "registry", Message: problem.Error()})
+		}
+	}
+
+	for _, source := range catalogs {
+		loaded, loadProblems, err := loadCatalog(source.sourceUrls())
+		for _, problem := range loadProblems {
+			fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", source.id, problem)
+		}
+		if err != nil {
+			findings = append(findings, lintFinding{Catalog: source.id, Severity: "error", Kind: "load", Message: err.Error()})
+			continue
+		}
+		referenceId := source.referenceId
+		if referenceId == "" {
+			referenceId = resolveReferenceId(*loaded)
+		}
+		for _, finding := range lintCatalog(*loaded, referenceId) {
+			finding.Catalog = source.id
+			findings = append(findings, finding)
+		}
+	}
+
+	errors, warnings := countFindings(findings)
+	if *asJson {
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, finding := range findings {
+			if finding.Catalog != "" {
+				fmt.Print(finding.Catalog + ": ")
+			}
+			fmt.Println(finding)
+		}
+		fmt.Printf("%d errors, %d warnings\n", errors, warnings)
+	}
+
+	if errors > 0 || (*strict && warnings > 0) {
+		return fmt.Errorf("lint found %d errors and %d warnings", errors, warnings)
+	}
+	return nil
+}
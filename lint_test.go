@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+func TestLintCatalog(t *testing.T) {
+	mapping := func(ids ...string) []layer2.Mapping {
+		return []layer2.Mapping{{ReferenceId: "CCC", Identifiers: ids}}
+	}
+	requirements := []layer2.AssessmentRequirement{{Id: "R1", Text: "MUST"}}
+	c := layer2.Catalog{
+		Capabilities: []layer2.Capability{
+			{Id: "F01", Title: "Encryption"},
+			{Title: "No id"},
+			{Id: "F02"},
+			{Id: "F01", Title: "Encryption again"},
+		},
+		Threats: []layer2.Threat{
+			{Id: "TH01", Title: "Interception", Capabilities: mapping("F01")},
+			{Title: "No id", Capabilities: mapping("F01")},
+			{Id: "TH02", Capabilities: mapping("F01")},
+			{Id: "TH03"},
+			{Id: "TH04", Title: "Untitled capability", Capabilities: mapping("F02")},
+			{Id: "TH05", Title: "Unknown capability", Capabilities: mapping("F99")},
+			{Id: "TH06", Title: "Other catalog", Capabilities: []layer2.Mapping{{ReferenceId: "NIST", Identifiers: []string{"X"}}}},
+			{Id: "TH01", Title: "Interception again", Capabilities: mapping("F01")},
+		},
+		ControlFamilies: []layer2.ControlFamily{
+			{Title: "Data", Controls: []layer2.Control{
+				{Id: "C01", ThreatMappings: mapping("TH01"), AssessmentRequirements: requirements},
+				{Title: "No id"},
+				{Id: "C02", ThreatMappings: mapping("TH02", "TH99"), AssessmentRequirements: requirements},
+				{Id: "C03", ThreatMappings: mapping("TH01")},
+				{Id: "C04", ThreatMappings: mapping("TH01"), AssessmentRequirements: requirements},
+				{Id: "C04", ThreatMappings: mapping("TH01"), AssessmentRequirements: requirements},
+			}},
+			{Title: "Logging", Controls: []layer2.Control{
+				{Id: "C01", ThreatMappings: mapping("TH01"), AssessmentRequirements: requirements},
+			}},
+		},
+	}
+
+	want := []lintFinding{
+		{Severity: "warning", Kind: "skipped", Message: "capability #2 has no id"},
+		{Severity: "warning", Kind: "skipped", Id: "F02", Message: "capability has no title"},
+		{Severity: "error", Kind: "duplicate-id", Id: "F01", Message: "capability is defined more than once"},
+		{Severity: "warning", Kind: "skipped", Message: "threat #2 has no id"},
+		{Severity: "warning", Kind: "skipped", Id: "TH02", Message: "threat has no title"},
+		{Severity: "warning", Kind: "unmapped", Id: "TH03", Message: "threat maps to no capabilities"},
+		{Severity: "warning", Kind: "skipped", Id: "TH03", Message: "threat has no title"},
+		{Severity: "error", Kind: "dangling-reference", Id: "TH04", Message: "threat maps to unknown capability F02"},
+		{Severity: "error", Kind: "dangling-reference", Id: "TH05", Message: "threat maps to unknown capability F99"},
+		{Severity: "error", Kind: "duplicate-id", Id: "TH01", Message: "threat is defined more than once"},
+		{Severity: "warning", Kind: "skipped", Message: "control #2 in Data has no id"},
+		{Severity: "error", Kind: "dangling-reference", Id: "C02", Message: "control maps to unknown threat TH02"},
+		{Severity: "error", Kind: "dangling-reference", Id: "C02", Message: "control maps to unknown threat TH99"},
+		{Severity: "warning", Kind: "no-requirements", Id: "C03", Message: "control has no assessment requirements"},
+		{Severity: "error", Kind: "duplicate-id", Id: "C01", Message: "control is defined 2 times, in Data, Logging"},
+		{Severity: "error", Kind: "duplicate-id", Id: "C04", Message: "control is defined 2 times, in Data"},
+	}
+	got := lintCatalog(c, "CCC")
+	for n := 0; n < len(got) || n < len(want); n++ {
+		switch {
+		case n >= len(got):
+			t.Errorf("missing finding %v", want[n])
+		case n >= len(want):
+			t.Errorf("unexpected finding %v", got[n])
+		case got[n] != want[n]:
+			t.Errorf("finding %d = %v, want %v", n+1, got[n], want[n])
+		}
+	}
+}
+
+func TestLintCatalogClean(t *testing.T) {
+	c := layer2.Catalog{
+		Capabilities: []layer2.Capability{{Id: "F01", Title: "Encryption"}},
+		Threats: []layer2.Threat{{
+			Id:           "TH01",
+			Title:        "Interception",
+			Capabilities: []layer2.Mapping{{ReferenceId: "CCC", Identifiers: []string{"F01"}}},
+		}},
+		ControlFamilies: []layer2.ControlFamily{{Title: "Data", Controls: []layer2.Control{{
+			Id:                     "C01",
+			ThreatMappings:         []layer2.Mapping{{ReferenceId: "CCC", Identifiers: []string{"TH01"}}},
+			AssessmentRequirements: []layer2.AssessmentRequirement{{Id: "R1"}},
+		}}}},
+	}
+	if findings := lintCatalog(c, "CCC"); len(findings) > 0 {
+		t.Errorf("expected no findings, got %v", findings)
+	}
+}
+
+func TestFindingsSummary(t *testing.T) {
+	tests := []struct {
+		findings []lintFinding
+		want     string
+	}{
+		{[]lintFinding{{Severity: "error"}, {Severity: "error"}}, "2 catalog errors"},
+		{[]lintFinding{{Severity: "warning"}}, "1 catalog warnings"},
+		{[]lintFinding{{Severity: "warning"}, {Severity: "error"}, {Severity: "warning"}}, "1 catalog errors, 2 catalog warnings"},
+	}
+	for _, test := range tests {
+		if got := findingsSummary(test.findings); got != test.want {
+			t.Errorf("findingsSummary(%v) = %q, want %q", test.findings, got, test.want)
+		}
+	}
+}
@@ -9,6 +9,18 @@ type listKeyMap struct {
 	list.KeyMap
 	finalizeSelection key.Binding
 	makeSelection     key.Binding
+	editMetadata      key.Binding
+	refine            key.Binding
+	back              key.Binding
+	applicability     key.Binding
+	showFiltered      key.Binding
+	showDetail        key.Binding
+	editPolicy        key.Binding
+	showCrosswalk     key.Binding
+	pickGuidelines    key.Binding
+	browse            key.Binding
+	showAnalysis      key.Binding
+	showWarnings      key.Binding
 }
 
 func newListKeyMap() *listKeyMap {
@@ -42,6 +54,54 @@ func newListKeyMap() *listKeyMap {
 			key.WithKeys(" "),
 			key.WithHelp("space", "continue"),
 		),
+		editMetadata: key.NewBinding(
+			key.WithKeys("m"),
+			key.WithHelp("m", "edit metadata"),
+		),
+		refine: key.NewBinding(
+			key.WithKeys("right"),
+			key.WithHelp("→", "threats & controls"),
+		),
+		back: key.NewBinding(
+			key.WithKeys("left", "esc"),
+			key.WithHelp("←", "back"),
+		),
+		applicability: key.NewBinding(
+			key.WithKeys("a"),
+			key.WithHelp("a", "applicability"),
+		),
+		showFiltered: key.NewBinding(
+			key.WithKeys("f"),
+			key.WithHelp("f", "filtered requirements"),
+		),
+		showDetail: key.NewBinding(
+			key.WithKeys("d"),
+			key.WithHelp("d", "details"),
+		),
+		editPolicy: key.NewBinding(
+			key.WithKeys("p"),
+			key.WithHelp("p", "policy"),
+		),
+		showCrosswalk: key.NewBinding(
+			key.WithKeys("c"),
+			key.WithHelp("c", "crosswalk"),
+		),
+		pickGuidelines: key.NewBinding(
+			key.WithKeys("g"),
+			key.WithHelp("g", "select by guideline"),
+		),
+		browse: key.NewBinding(
+			key.WithKeys("v"),
+			key.WithHelp("v", "browse threats/controls"),
+		),
+		showAnalysis: key.NewBinding(
+			key.WithKeys("n"),
+			key.WithHelp("n", "coverage analysis"),
+		),
+		showWarnings: key.NewBinding(
+			key.WithKeys("w"),
+			key.WithHelp("w", "catalog warnings"),
+		),
 	}
 
 	return km
@@ -60,8 +120,67 @@ func (k listKeyMap) ShortHelp() []key.Binding {
 						key.WithKeys("backspace"),
 						key.WithHelp("backspace", "deselect"),
 					),
+					k.editMetadata,
+					k.refine,
+					k.showDetail,
+					k.applicability,
+					k.showFiltered,
+					k.editPolicy,
+					k.showCrosswalk,
+					k.pickGuidelines,
+					k.browse,
+					k.showAnalysis,
+					k.showWarnings,
+				}
+			case "applicability":
+				return []key.Binding{
+					k.makeSelection,
+				}
+			case "filtered", "detail", "crosswalk", "explaining", "analysis", "warnings":
+				return []key.Binding{
+					k.back,
+				}
+			case "refining":
+				return []key.Binding{
+					key.NewBinding(
+						key.WithKeys("enter"),
+						key.WithHelp("enter", "include/exclude"),
+					),
+					k.back,
+				}
+			case "browsing":
+				return []key.Binding{
+					key.NewBinding(
+						key.WithKeys("enter"),
+						key.WithHelp("enter", "include/exclude"),
+					),
+					key.NewBinding(
+						key.WithKeys("v"),
+						key.WithHelp("v", "next view"),
+					),
+					k.back,
+				}
+			case "guidelines":
+				return []key.Binding{
+					key.NewBinding(
+						key.WithKeys("enter"),
+						key.WithHelp("enter", "pick/unpick"),
+					),
+					key.NewBinding(
+						key.WithKeys(" "),
+						key.WithHelp("space", "select capabilities"),
+					),
+					k.back,
+				}
+			case "policy":
+				return []key.Binding{
+					key.NewBinding(
+						key.WithKeys("enter"),
+						key.WithHelp("enter", "edit"),
+					),
+					k.back,
 				}
-			case "naming":
+			case "metadata", "policyEditing":
 				return []key.Binding{
 					k.makeSelection,
 				}
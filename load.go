@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 	"slices"
 	"sort"
 	"strings"
@@ -27,25 +26,26 @@ type availableControl struct {
 	FamilyDescription string
 }
 
-func loadData(urls []string) (output []availableCapability) {
-	var catalog layer2.Catalog
-
-	// Try to load from cache first
-	if cached, err := loadFromCache(urls); err == nil {
-		catalog = *cached
-	} else {
-		// If cache miss or error, load from URLs
-		err := catalog.LoadFiles(urls)
-		if err != nil {
-			fmt.Printf("Error loading catalog: %v\n", err)
-			os.Exit(1)
-		}
+// loadData loads the source catalog and links each capability to the
+// threats it faces and the controls mitigating them. When the source sets
+// no reference-id for the catalog's own entries, it is derived from the
+// catalog contents. Cache problems that didn't stop the catalog from
+// loading are returned alongside it.
+func loadData(source catalogItem) (output []availableCapability, problems []error, err error) {
+	urls := source.sourceUrls()
+	loaded, problems, err := loadCatalog(urls)
+	if err != nil {
+		return nil, problems, fmt.Errorf("error loading catalog: %w", err)
+	}
+	catalog = *loaded
+	catalogSource = source
+	catalogUrls = urls
 
-		// Save to cache for next time
-		if err := saveToCache(urls, &catalog); err != nil {
-			fmt.Printf("Warning: Failed to cache catalog: %v\n", err)
-		}
+	referenceId := source.referenceId
+	if referenceId == "" {
+		referenceId = resolveReferenceId(catalog)
 	}
+	catalogReferenceId = referenceId
 
 	for _, cap := range catalog.Capabilities {
 		if cap.Id == "" || cap.Title == "" {
@@ -59,7 +59,7 @@ func loadData(urls []string) (output []availableCapability) {
 				continue
 			}
 			for _, tc := range threat.Capabilities {
-				if tc.ReferenceId != "CCC" {
+				if tc.ReferenceId != referenceId {
 					continue
 				}
 				for _, mappedCapabilityId := range tc.Identifiers {
@@ -77,7 +77,7 @@ func loadData(urls []string) (output []availableCapability) {
 					continue
 				}
 				for _, ct := range control.ThreatMappings {
-					if ct.ReferenceId != "CCC" {
+					if ct.ReferenceId != referenceId {
 						continue
 					}
 					for _, threatId := range ct.Identifiers {
@@ -97,50 +97,114 @@ func loadData(urls []string) (output []availableCapability) {
 		output = append(output, sortedCapability)
 	}
 
-	return output
+	catalogContents = output
+	return output, problems, nil
 }
 
-func loadChoicesWithUrls(urls []string) (choices []list.Item) {
-	data := loadData(urls)
-
-	width := 80
-	if m, ok := currentModel.(model); ok {
-		width = m.descWidth
+// resolveReferenceId picks the reference-id the catalog uses for its own
+// entries. A mapping reference declared with the catalog's metadata ID is
+// taken as is. Otherwise the reference-id whose mappings link the most
+// threats to loaded capabilities and controls to loaded threats wins,
+// preferring those declared in the metadata, and falling back to the
+// catalog's metadata ID when nothing links up.
+func resolveReferenceId(catalog layer2.Catalog) string {
+	declared := make(map[string]bool)
+	for _, reference := range catalog.Metadata.MappingReferences {
+		if reference.Id == catalog.Metadata.Id && reference.Id != "" {
+			return reference.Id
+		}
+		declared[reference.Id] = true
 	}
 
-	minStatsWidth := 20
+	capabilityIds := make(map[string]bool)
+	for _, capability := range catalog.Capabilities {
+		capabilityIds[capability.Id] = true
+	}
+	threatIds := make(map[string]bool)
+	for _, threat := range catalog.Threats {
+		threatIds[threat.Id] = true
+	}
 
-	for _, capability := range data {
-		var threatList []string
-		var controlList []string
-		for _, threat := range capability.Threats {
-			threatList = append(threatList, threat.Data.Id)
-			for _, control := range threat.Controls {
-				if !slices.Contains(controlList, control.Data.Id) {
-					controlList = append(controlList, control.Data.Id)
+	scores := make(map[string]int)
+	for _, threat := range catalog.Threats {
+		for _, mapping := range threat.Capabilities {
+			for _, id := range mapping.Identifiers {
+				if capabilityIds[id] {
+					scores[mapping.ReferenceId]++
+				}
+			}
+		}
+	}
+	for _, family := range catalog.ControlFamilies {
+		for _, control := range family.Controls {
+			for _, mapping := range control.ThreatMappings {
+				for _, id := range mapping.Identifiers {
+					if threatIds[id] {
+						scores[mapping.ReferenceId]++
+					}
 				}
 			}
 		}
+	}
 
-		stats := fmt.Sprintf(" | Threats: %v | Controls: %v", len(threatList), len(controlList))
-		var description string
+	best, bestScore, bestDeclared := catalog.Metadata.Id, 0, false
+	for id, score := range scores {
+		better := score > bestScore || (score == bestScore && id < best)
+		if declared[id] != bestDeclared {
+			better = declared[id]
+		}
+		if better {
+			best, bestScore, bestDeclared = id, score, declared[id]
+		}
+	}
+	return best
+}
 
-		if width <= minStatsWidth {
-			description = stats
-		} else {
-			description = strings.Split(capability.Data.Description, "\n")[0]
-			availableWidth := width - len(stats)
-			if availableWidth > 0 && len(description) > availableWidth {
-				description = description[:availableWidth-3] + "..."
+// describeCapability summarises a capability for the list, fitting its
+// description beside the threat and control counts within width
+func describeCapability(capability availableCapability, width int) string {
+	const minStatsWidth = 20
+
+	var threatList []string
+	var controlList []string
+	for _, threat := range capability.Threats {
+		threatList = append(threatList, threat.Data.Id)
+		for _, control := range threat.Controls {
+			if !slices.Contains(controlList, control.Data.Id) {
+				controlList = append(controlList, control.Data.Id)
 			}
-			description += stats
 		}
+	}
+
+	stats := fmt.Sprintf(" | Threats: %v | Controls: %v", len(threatList), len(controlList))
+	if width <= minStatsWidth {
+		return stats
+	}
+	description := strings.Split(capability.Data.Description, "\n")[0]
+	availableWidth := width - len(stats)
+	if availableWidth > 0 && len(description) > availableWidth {
+		description = description[:availableWidth-3] + "..."
+	}
+	return description + stats
+}
 
+func loadChoices(source catalogItem) (choices []list.Item, problems []error, err error) {
+	data, problems, err := loadData(source)
+	if err != nil {
+		return nil, problems, err
+	}
+
+	width := 80
+	if m, ok := currentModel.(model); ok {
+		width = m.descWidth
+	}
+
+	for _, capability := range data {
 		choice := item{
 			id:          capability.Data.Id,
 			title:       capability.Data.Title,
 			capability:  capability,
-			description: description,
+			description: describeCapability(capability, width),
 		}
 		choices = append(choices, choice)
 	}
@@ -149,5 +213,5 @@ func loadChoicesWithUrls(urls []string) (choices []list.Item) {
 		return choices[i].(item).capability.Data.Id < choices[j].(item).capability.Data.Id
 	})
 
-	return choices
+	return choices, problems, nil
 }
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+func TestResolveReferenceId(t *testing.T) {
+	threats := []layer2.Threat{{
+		Id: "TH01",
+		Capabilities: []layer2.Mapping{
+			{ReferenceId: "CCC", Identifiers: []string{"F01", "F02"}},
+			{ReferenceId: "OLD", Identifiers: []string{"F01"}},
+		},
+	}}
+	capabilities := []layer2.Capability{{Id: "F01"}, {Id: "F02"}}
+	references := func(ids ...string) (declared []layer2.MappingReference) {
+		for _, id := range ids {
+			declared = append(declared, layer2.MappingReference{Id: id})
+		}
+		return declared
+	}
+
+	tests := []struct {
+		name     string
+		metadata layer2.Metadata
+		threats  []layer2.Threat
+		want     string
+	}{
+		{"most links", layer2.Metadata{Id: "FINOS"}, threats, "CCC"},
+		{"declared beats more links", layer2.Metadata{Id: "FINOS", MappingReferences: references("OLD")}, threats, "OLD"},
+		{"declared with the metadata id", layer2.Metadata{Id: "SELF", MappingReferences: references("NIST", "SELF")}, threats, "SELF"},
+		{"declared but unused", layer2.Metadata{Id: "FINOS", MappingReferences: references("NIST")}, threats, "CCC"},
+		{"nothing links up", layer2.Metadata{Id: "FINOS"}, nil, "FINOS"},
+	}
+	for _, test := range tests {
+		c := layer2.Catalog{Metadata: test.metadata, Capabilities: capabilities, Threats: test.threats}
+		if got := resolveReferenceId(c); got != test.want {
+			t.Errorf("%s: resolveReferenceId = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
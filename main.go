@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 
@@ -10,10 +12,19 @@ import (
 )
 
 var (
-	catalogName string
-	catalog     layer2.Catalog
+	catalogMetadata    layer2.Metadata
+	catalog            layer2.Catalog
+	catalogSource      catalogItem
+	catalogUrls        []string
+	catalogContents    []availableCapability
+	catalogReferenceId string
+	applicabilityLevel layer2.Category
+	embedContent       bool // write full definitions instead of shared references
 
 	selectedCapabilities      map[string]item
+	excludedThreats           map[string]bool
+	excludedControls          map[string]bool
+	controlPolicies           map[string]policyAttributes
 	triedToReselectCapability map[string]bool // Just having fun with this one
 
 	titleText = "Controls Canvas"
@@ -28,14 +39,85 @@ var (
 	statusMessageStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.AdaptiveColor{Light: "#04B575", Dark: "#04B575"}).
 				Render
+
+	errorMessageStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.AdaptiveColor{Light: "#D7263D", Dark: "#FF5F5F"}).
+				Render
 )
 
 func main() {
 	selectedCapabilities = make(map[string]item)
 	triedToReselectCapability = make(map[string]bool)
+	excludedThreats = make(map[string]bool)
+	excludedControls = make(map[string]bool)
+	controlPolicies = make(map[string]policyAttributes)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "generate":
+			exitWith(runGenerate(os.Args[2:]))
+		case "edit":
+			exitWith(runEdit(os.Args[2:]))
+		case "cache":
+			exitWith(runCache(os.Args[2:]))
+		case "schema":
+			exitWith(runSchema(os.Args[2:]))
+		case "analyze":
+			exitWith(runAnalyze(os.Args[2:]))
+		case "lint":
+			exitWith(runLint(os.Args[2:]))
+		}
+	}
+
+	exitWith(runInteractive(os.Args[1:]))
+}
+
+// runInteractive starts the TUI with the catalogs selected by flags
+func runInteractive(args []string) error {
+	fs := flag.NewFlagSet("controls-canvas", flag.ContinueOnError)
+	out := fs.String("out", "", "Path to write the output catalog to (default \"output\" with the format's extension)")
+	backup := fs.Bool("backup", false, "Keep the previous output at <out>.bak when replacing it")
+	fs.BoolVar(&embedContent, "embed", false, "Embed the selected capabilities, threats and controls instead of referencing them")
+	var sources sourceOptions
+	sources.register(fs)
+	cacheSettings.register(fs)
+	outputSettings.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := cacheSettings.validate(); err != nil {
+		return err
+	}
+	if err := outputSettings.validate(); err != nil {
+		return err
+	}
+	if *out == "" {
+		*out = outputSettings.defaultPath()
+	}
+	if outputSettings.format == "policy" {
+		if err := restorePolicies(*out); err != nil {
+			return err
+		}
+	}
+
+	catalogs, problems, err := sources.catalogs()
+	if err != nil {
+		return err
+	}
+
+	m := newCatalogInputModel(catalogs, problems)
+	m.outputPath = *out
+	m.backup = *backup
+	if _, err := tea.NewProgram(m, tea.WithAltScreen()).Run(); err != nil {
+		return fmt.Errorf("running model for catalog input: %w", err)
+	}
+	return nil
+}
 
-	if _, err := tea.NewProgram(newCatalogInputModel(), tea.WithAltScreen()).Run(); err != nil {
-		fmt.Println("Error running model for catalog input:", err)
+// exitWith terminates a subcommand, reporting err if it is set
+func exitWith(err error) {
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintln(os.Stderr, "Error:", err)
 		os.Exit(1)
 	}
 	os.Exit(0)
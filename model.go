@@ -1,57 +1,61 @@
 package main
 
 import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
-	"gopkg.in/yaml.v3"
+	"github.com/revanite-io/sci/layer2"
 )
 
 type model struct {
 	list         list.Model
+	form         list.Model
+	refine       list.Model
+	levels       list.Model
+	policy       list.Model
+	guidelines   list.Model
+	browse       list.Model
+	viewport     viewport.Model
 	keys         *listKeyMap
 	delegateKeys *delegateKeyMap
 	state        string
 	preview      string
 	width        int
 	height       int
-	selectedUrls []string
+	selected     catalogItem
+	outputPath   string
+	pathInput    string
+	backup       bool
+	diff         string
+	outputExists bool
+	companions   []string
+	editing      string
+	picked       map[guideline]bool
+	browseMode   string
 	descWidth    int
 	sizeWarning  string
+	problems     []string
+	loadProblems []string
+	failure      string
+	warnings     []lintFinding
 }
 
-type catalogItem struct {
-	title       string
-	description string
-	urls        []string
-}
-
-func (i catalogItem) Title() string       { return i.title }
-func (i catalogItem) Description() string { return i.description }
-func (i catalogItem) FilterValue() string { return i.title }
-
-func newCatalogInputModel() model {
+func newCatalogInputModel(catalogs []catalogItem, problems []error) model {
 	var (
 		delegateKeys = newDelegateKeyMap()
 		listKeys     = newListKeyMap()
 	)
 
-	items := []list.Item{
-		catalogItem{
-			title:       "Common Cloud Controls",
-			description: "Default catalog with cloud security controls",
-			urls: []string{
-				"https://raw.githubusercontent.com/finos/common-cloud-controls/refs/heads/main/common/controls.yaml",
-				"https://raw.githubusercontent.com/finos/common-cloud-controls/refs/heads/main/common/threats.yaml",
-				"https://raw.githubusercontent.com/finos/common-cloud-controls/refs/heads/main/common/capabilities.yaml",
-			},
-		},
-		catalogItem{
-			title:       "Future reference options will be added here",
-			description: "(Selecting this placeholder will just close the program)",
-			urls:        []string{},
-		},
+	var items []list.Item
+	for _, c := range catalogs {
+		items = append(items, c)
 	}
 
 	// Setup list
@@ -69,11 +73,56 @@ func newCatalogInputModel() model {
 		return listKeys.ShortHelp()
 	}
 
+	refine := list.New(nil, newItemDelegate(delegateKeys), 0, 0)
+	refine.Styles.Title = titleStyle
+	refine.KeyMap = listKeys.KeyMap
+	refine.SetFilteringEnabled(false)
+	refine.SetShowHelp(false)
+
+	policy := list.New(nil, newItemDelegate(delegateKeys), 0, 0)
+	policy.Title = "Policy"
+	policy.Styles.Title = titleStyle
+	policy.KeyMap = listKeys.KeyMap
+	policy.SetFilteringEnabled(false)
+	policy.SetShowHelp(false)
+
+	guidelines := list.New(nil, newItemDelegate(delegateKeys), 0, 0)
+	guidelines.Title = "Select by Guideline"
+	guidelines.Styles.Title = titleStyle
+	guidelines.KeyMap = listKeys.KeyMap
+	guidelines.SetFilteringEnabled(false)
+	guidelines.SetShowHelp(false)
+
+	browse := list.New(nil, newItemDelegate(delegateKeys), 0, 0)
+	browse.Styles.Title = titleStyle
+	browse.KeyMap = listKeys.KeyMap
+	browse.SetFilteringEnabled(false)
+	browse.SetShowHelp(false)
+
+	levels := list.New(nil, newItemDelegate(delegateKeys), 0, 0)
+	levels.Title = "Select Applicability"
+	levels.Styles.Title = titleStyle
+	levels.KeyMap = listKeys.KeyMap
+	levels.SetFilteringEnabled(false)
+	levels.SetShowHelp(false)
+
 	m := model{
 		list:         catalogCanvas,
+		levels:       levels,
+		policy:       policy,
+		guidelines:   guidelines,
+		browse:       browse,
+		picked:       make(map[guideline]bool),
+		viewport:     viewport.New(0, 0),
+		form:         newMetadataForm(catalogMetadata),
+		refine:       refine,
 		keys:         listKeys,
 		delegateKeys: delegateKeys,
 		state:        "catalog",
+		outputPath:   "output.yaml",
+	}
+	for _, problem := range problems {
+		m.problems = append(m.problems, problem.Error())
 	}
 	currentModel = m
 	return m
@@ -92,8 +141,8 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		h, v := appStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		m.resizeList()
+		h, _ := appStyle.GetFrameSize()
 
 		m.descWidth = (msg.Width-h)/2 - 10
 
@@ -104,8 +153,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		} else {
 			m.sizeWarning = ""
 			if m.state == "selecting" {
-				choices := loadChoicesWithUrls(m.selectedUrls)
-				m.list.SetItems(choices)
+				m.describeChoices()
 			}
 		}
 
@@ -119,15 +167,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			switch msg.Type {
 			case tea.KeyEnter:
 				if item, ok := m.list.SelectedItem().(catalogItem); ok {
-					m.selectedUrls = item.urls
-					if item.title == "Common Cloud Controls" {
-						choices := loadChoicesWithUrls(item.urls)
-						m.list.SetItems(choices)
-						m.list.Title = titleText
-						m.state = "naming"
-					} else {
-						return m, tea.Quit
+					m.selected = item
+					choices, problems, err := loadChoices(item)
+					m.setLoadProblems(problems)
+					if err != nil {
+						m.problems = append(m.problems, m.loadProblems...)
+						m.problems = append(m.problems, item.title+": "+err.Error())
+						m.resizeList()
+						return m, nil
 					}
+					m.list.SetItems(choices)
+					m.list.Title = titleText
+					m.warnings = lintLoadedCatalog()
+					m.openApplicability()
 				}
 				return m, nil
 			case tea.KeyUp, tea.KeyDown:
@@ -141,46 +193,299 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, nil
 
-		case m.state == "naming":
+		case m.state == "metadata":
+			if updateForm(&m.form, msg) {
+				catalogMetadata = metadataFromForm(m.form)
+				m.list.Title = titleText + ": " + catalogMetadata.Title
+				m.state = "selecting"
+			}
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.editMetadata):
+			m.openMetadataForm()
+			return m, nil
+
+		case m.state == "applicability":
+			if key.Matches(msg, m.keys.makeSelection) {
+				if i, ok := m.levels.SelectedItem().(applicabilityItem); ok {
+					applicabilityLevel = i.category
+				}
+				if catalogMetadata.Title == "" {
+					m.openMetadataForm()
+				} else {
+					m.state = "selecting"
+				}
+				return m, nil
+			}
+			newLevelsModel, cmd := m.levels.Update(msg)
+			m.levels = newLevelsModel
+			return m, cmd
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.applicability):
+			m.openApplicability()
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.showFiltered):
+			m.viewport.SetContent(renderFilteredRequirements())
+			m.viewport.GotoTop()
+			m.state = "filtered"
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.showDetail):
+			if i, ok := m.list.SelectedItem().(item); ok {
+				m.viewport.SetContent(renderCapabilityDetail(i.capability, m.viewport.Width))
+				m.viewport.GotoTop()
+				m.state = "detail"
+			}
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.showWarnings):
+			m.viewport.SetContent(renderWarnings(m.warnings))
+			m.viewport.GotoTop()
+			m.state = "warnings"
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.showAnalysis):
+			m.viewport.SetContent(renderAnalysis(analyzeCoverage()))
+			m.viewport.GotoTop()
+			m.state = "analysis"
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.showCrosswalk):
+			m.viewport.SetContent(renderCrosswalk())
+			m.viewport.GotoTop()
+			m.state = "crosswalk"
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.browse):
+			m.openBrowseMode(browseModes[0])
+			return m, nil
+
+		case m.state == "browsing":
+			switch {
+			case key.Matches(msg, m.keys.back):
+				m.state = "selecting"
+				return m, nil
+			case key.Matches(msg, m.keys.browse):
+				next := slices.Index(browseModes, m.browseMode) + 1
+				if next == len(browseModes) {
+					m.state = "selecting"
+				} else {
+					m.openBrowseMode(browseModes[next])
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.makeSelection):
+				if i, ok := m.browse.SelectedItem().(browseItem); ok {
+					if status := toggleBrowseItem(i, m.list.Items()); status != "" {
+						return m, m.browse.NewStatusMessage(statusMessageStyle(status))
+					}
+				}
+				return m, nil
+			}
+			newBrowseModel, cmd := m.browse.Update(msg)
+			m.browse = newBrowseModel
+			return m, cmd
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.pickGuidelines):
+			m.guidelines.SetItems(guidelineItems(m.picked))
+			m.guidelines.Select(0)
+			m.state = "guidelines"
+			return m, nil
+
+		case m.state == "guidelines":
+			switch {
+			case key.Matches(msg, m.keys.back):
+				m.state = "selecting"
+				return m, nil
+			case key.Matches(msg, m.keys.makeSelection):
+				if i, ok := m.guidelines.SelectedItem().(guidelineItem); ok {
+					if m.picked[i.guideline] {
+						delete(m.picked, i.guideline)
+					} else {
+						m.picked[i.guideline] = true
+					}
+				}
+				return m, nil
+			case key.Matches(msg, m.keys.finalizeSelection):
+				inclusions, unresolved := resolveGuidelines(pickedGuidelines(m.picked))
+				includeGuidelineInclusions(inclusions, m.list.Items())
+				lines := explainGuidelineInclusions(inclusions, unresolved)
+				if len(lines) == 0 {
+					lines = []string{"Pick guidelines with enter first."}
+				}
+				m.viewport.SetContent(strings.Join(lines, "\n"))
+				m.viewport.GotoTop()
+				m.state = "explaining"
+				return m, nil
+			}
+			newGuidelinesModel, cmd := m.guidelines.Update(msg)
+			m.guidelines = newGuidelinesModel
+			return m, cmd
+
+		case m.state == "filtered" || m.state == "detail" || m.state == "crosswalk" || m.state == "explaining" || m.state == "analysis" || m.state == "warnings":
+			if key.Matches(msg, m.keys.back) {
+				m.state = "selecting"
+				return m, nil
+			}
+			newViewport, cmd := m.viewport.Update(msg)
+			m.viewport = newViewport
+			return m, cmd
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.editPolicy):
+			m.policy.SetItems(policyItems())
+			m.policy.Select(0)
+			m.state = "policy"
+			return m, nil
+
+		case m.state == "policy":
+			switch {
+			case key.Matches(msg, m.keys.back):
+				m.state = "selecting"
+				return m, nil
+			case key.Matches(msg, m.keys.makeSelection):
+				if i, ok := m.policy.SelectedItem().(policyItem); ok {
+					m.editing = i.control.Data.Id
+					m.form = newPolicyForm(m.editing)
+					m.state = "policyEditing"
+					m.resizeList()
+				}
+				return m, nil
+			}
+			newPolicyModel, cmd := m.policy.Update(msg)
+			m.policy = newPolicyModel
+			return m, cmd
+
+		case m.state == "policyEditing":
+			if msg.Type == tea.KeyEsc {
+				m.state = "policy"
+				return m, nil
+			}
+			if updateForm(&m.form, msg) {
+				controlPolicies[m.editing] = policyFromForm(m.form)
+				m.policy.SetItems(policyItems())
+				m.state = "policy"
+			}
+			return m, nil
+
+		case m.state == "selecting" && key.Matches(msg, m.keys.refine):
+			if i, ok := m.list.SelectedItem().(item); ok {
+				m.refine.Title = i.id + ": " + i.title
+				m.refine.SetItems(refineItems(i.capability))
+				m.refine.Select(0)
+				m.state = "refining"
+			}
+			return m, nil
+
+		case m.state == "refining":
+			switch {
+			case key.Matches(msg, m.keys.back):
+				m.state = "selecting"
+				return m, nil
+			case key.Matches(msg, m.keys.makeSelection):
+				if i, ok := m.refine.SelectedItem().(refineItem); ok {
+					return m, m.refine.NewStatusMessage(statusMessageStyle(toggleRefineItem(i)))
+				}
+				return m, nil
+			}
+			newRefineModel, cmd := m.refine.Update(msg)
+			m.refine = newRefineModel
+			return m, cmd
+
+		case m.state == "pathing":
 			switch msg.Type {
 			case tea.KeyEnter:
-				if catalogName != "" {
-					m.list.Title = titleText + ": " + catalogName
-					m.state = "selecting"
-					return m, nil
+				if m.pathInput != "" {
+					previousPath := m.outputPath
+					m.outputPath = m.pathInput
+					if err := m.preparePreview(); err != nil {
+						m.outputPath = previousPath
+						m.failure = "Failed to generate preview: " + err.Error()
+					}
+					m.state = "confirming"
 				}
+			case tea.KeyEsc:
+				m.state = "confirming"
 			case tea.KeyBackspace:
-				if len(catalogName) > 0 {
-					catalogName = catalogName[:len(catalogName)-1]
+				if path := []rune(m.pathInput); len(path) > 0 {
+					m.pathInput = string(path[:len(path)-1])
 				}
+			case tea.KeySpace:
+				m.pathInput += " "
 			case tea.KeyRunes:
-				catalogName += string(msg.Runes)
+				m.pathInput += string(msg.Runes)
 			}
 			return m, nil
 
 		case key.Matches(msg, m.keys.finalizeSelection):
 			if m.state == "selecting" {
-				catalog := generateOutputCatalog()
-				data, err := yaml.Marshal(catalog)
-				if err != nil {
-					return m, tea.Println("Failed to generate preview: " + err.Error())
+				if !m.checkMetadata() {
+					return m, nil
+				}
+				if err := m.preparePreview(); err != nil {
+					return m, m.list.NewStatusMessage(errorMessageStyle("Failed to generate preview: " + err.Error()))
 				}
-				m.preview = string(data)
 				m.state = "confirming"
 				return m, nil
 			}
 
 		case m.state == "confirming":
+			m.failure = ""
 			switch msg.String() {
 			case "y", "Y":
-				err := writeOutputCatalog("output.yaml")
+				if !m.checkMetadata() {
+					return m, nil
+				}
+				err := writeOutputCatalog(m.outputPath, m.backup)
 				if err != nil {
-					return m, tea.Println("Failed to write output.yaml: " + err.Error())
+					m.failure = err.Error()
+					return m, nil
 				}
 				return m, tea.Quit
 			case "n", "N":
 				m.state = "selecting"
 				return m, nil
+			case "o", "O":
+				m.pathInput = m.outputPath
+				m.state = "pathing"
+				return m, nil
+			case "b", "B":
+				m.backup = !m.backup
+				return m, nil
+			case "e", "E":
+				if outputSettings.format != "layer2" && outputSettings.format != "json" {
+					return m, nil
+				}
+				embedContent = !embedContent
+				if err := m.preparePreview(); err != nil {
+					embedContent = !embedContent
+					m.failure = "Failed to generate preview: " + err.Error()
+				}
+				return m, nil
+			case "f", "F":
+				previousFormat, previousPath := outputSettings.format, m.outputPath
+				m.outputPath = outputSettings.cycleFormat(m.outputPath)
+				var err error
+				if outputSettings.format == "policy" && len(controlPolicies) == 0 {
+					err = restorePolicies(m.outputPath)
+				}
+				if err == nil {
+					err = m.preparePreview()
+				}
+				if err != nil {
+					m.failure = "Failed to switch to " + outputSettings.outputFormat().title + ": " + err.Error()
+					outputSettings.format, m.outputPath = previousFormat, previousPath
+				}
+				return m, nil
+			case "s", "S":
+				if outputSettings.format != "evaluation" {
+					return m, nil
+				}
+				outputSettings.evaluationStubs = !outputSettings.evaluationStubs
+				if err := m.preparePreview(); err != nil {
+					outputSettings.evaluationStubs = !outputSettings.evaluationStubs
+					m.failure = "Failed to generate preview: " + err.Error()
+				}
+				return m, nil
 			}
 		}
 	}
@@ -192,6 +497,128 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// preparePreview renders the output catalog for the confirmation screen
+// and, if the output path already exists, the changes writing would make
+func (m *model) preparePreview() error {
+	files, err := renderOutputFiles(m.outputPath)
+	if err != nil {
+		return err
+	}
+	m.preview = string(files[0].data)
+	m.companions = nil
+	for _, file := range files[1:] {
+		m.companions = append(m.companions, file.path)
+	}
+	m.diff = ""
+	m.outputExists = false
+	if existing, err := os.ReadFile(m.outputPath); err == nil {
+		m.outputExists = true
+		m.diff = renderDiff(diffLines(string(existing), m.preview))
+	}
+	return nil
+}
+
+// openApplicability offers the loaded catalog's applicability categories,
+// skipping straight to the metadata form when it declares none
+func (m *model) openApplicability() {
+	choices := applicabilityChoices()
+	if len(choices) == 1 {
+		applicabilityLevel = layer2.Category{}
+		m.openMetadataForm()
+		return
+	}
+	m.levels.SetItems(choices)
+	m.levels.Select(0)
+	for n, choice := range choices {
+		if choice.(applicabilityItem).category.Id == applicabilityLevel.Id {
+			m.levels.Select(n)
+		}
+	}
+	m.state = "applicability"
+	m.resizeList()
+}
+
+// openBrowseMode lists the loaded catalog by threat or by control family
+func (m *model) openBrowseMode(mode string) {
+	items := threatBrowseItems()
+	if mode == "controls" {
+		items = controlBrowseItems()
+	}
+	m.browseMode = mode
+	m.browse.Title = browseTitles[mode]
+	m.browse.SetItems(items)
+	m.browse.Select(0)
+	m.state = "browsing"
+}
+
+// openMetadataForm shows the metadata form prefilled with the current
+// output catalog metadata
+func (m *model) openMetadataForm() {
+	m.form = newMetadataForm(catalogMetadata)
+	m.state = "metadata"
+	m.resizeList()
+}
+
+// checkMetadata opens the metadata form with its problems marked when the
+// catalog metadata isn't valid, such as metadata read from an existing
+// file, reporting whether it was valid
+func (m *model) checkMetadata() bool {
+	if validateMetadata(catalogMetadata) == nil {
+		return true
+	}
+	m.openMetadataForm()
+	validateForm(&m.form)
+	return false
+}
+
+// describeChoices fits the loaded capabilities' descriptions to the
+// current width, without loading the catalog again
+func (m *model) describeChoices() {
+	choices := m.list.Items()
+	for n, choice := range choices {
+		if i, ok := choice.(item); ok {
+			i.description = describeCapability(i.capability, m.descWidth)
+			choices[n] = i
+		}
+	}
+	m.list.SetItems(choices)
+}
+
+// setLoadProblems records the cache problems met loading the selected
+// catalog, shown beneath the list while selecting
+func (m *model) setLoadProblems(problems []error) {
+	m.loadProblems = nil
+	for _, problem := range problems {
+		m.loadProblems = append(m.loadProblems, m.selected.title+": "+problem.Error())
+	}
+}
+
+// resizeList fits the list to the window, leaving room for any problems
+// shown beneath it on the catalog screen, and for cache problems and the
+// catalog warnings line while selecting
+func (m *model) resizeList() {
+	h, v := appStyle.GetFrameSize()
+	if m.state == "catalog" {
+		v += len(m.problems)
+	} else {
+		v += len(m.loadProblems)
+		if len(m.warnings) > 0 {
+			v++
+		}
+	}
+	m.list.SetSize(m.width-h, m.height-v)
+	m.refine.SetSize(m.width-h, m.height-v)
+	m.levels.SetSize(m.width-h, m.height-v)
+	m.policy.SetSize(m.width-h, m.height-v)
+	m.guidelines.SetSize(m.width-h, m.height-v)
+	m.browse.SetSize(m.width-h, m.height-v)
+	m.viewport.Width = m.width - h
+	m.viewport.Height = m.height - v - 2
+
+	formFrame, _ := getFormStyle().GetFrameSize()
+	m.form.SetSize(m.width-h-formFrame, len(m.form.Items())*3)
+}
+
 func (m model) View() string {
 	const minWidth = 80
 	const minHeight = 24
@@ -204,24 +631,151 @@ func (m model) View() string {
 	var content string
 	if m.state == "catalog" {
 		content = m.list.View()
-	} else if m.state == "naming" {
+		if len(m.problems) > 0 {
+			var problems []string
+			for _, problem := range m.problems {
+				problems = append(problems, errorMessageStyle("! "+problem))
+			}
+			content = lipgloss.JoinVertical(lipgloss.Left, append([]string{content}, problems...)...)
+		}
+	} else if m.state == "metadata" {
 		content = lipgloss.JoinVertical(
 			lipgloss.Left,
 			m.list.Styles.Title.Render(m.list.Title),
-			"Enter catalog name: "+catalogName,
+			"Describe the output catalog:",
+			getFormStyle().Render(m.form.View()),
+			"tab/shift+tab: move between fields · enter: next field, save on the last",
 		)
-	} else if m.state == "confirming" {
+	} else if m.state == "refining" {
+		content = m.refine.View()
+	} else if m.state == "policy" {
+		content = m.policy.View()
+	} else if m.state == "policyEditing" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Policy for "+m.editing),
+			getFormStyle().Render(m.form.View()),
+			"tab/shift+tab: move between fields · enter: next field, save on the last · esc: cancel",
+		)
+	} else if m.state == "applicability" {
+		content = m.levels.View()
+	} else if m.state == "filtered" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Filtered requirements"),
+			m.viewport.View(),
+		)
+	} else if m.state == "detail" {
 		content = lipgloss.JoinVertical(
 			lipgloss.Left,
 			m.list.Styles.Title.Render(m.list.Title),
-			"Preview of output catalog:",
-			m.preview,
-			"\nWrite to file? (Y/N)",
+			m.viewport.View(),
+			detailLabelStyle.Render(fmt.Sprintf("%3.f%% · ↑/↓ scroll · ← back", m.viewport.ScrollPercent()*100)),
+		)
+	} else if m.state == "browsing" {
+		content = m.browse.View()
+	} else if m.state == "guidelines" {
+		content = m.guidelines.View()
+	} else if m.state == "explaining" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Selected by guideline"),
+			m.viewport.View(),
+			detailLabelStyle.Render(fmt.Sprintf("%3.f%% · ↑/↓ scroll · ← back", m.viewport.ScrollPercent()*100)),
+		)
+	} else if m.state == "warnings" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Catalog warnings"),
+			m.viewport.View(),
+			detailLabelStyle.Render(fmt.Sprintf("%3.f%% · ↑/↓ scroll · ← back", m.viewport.ScrollPercent()*100)),
+		)
+	} else if m.state == "analysis" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Coverage analysis"),
+			m.viewport.View(),
+			detailLabelStyle.Render(fmt.Sprintf("%3.f%% · ↑/↓ scroll · ← back", m.viewport.ScrollPercent()*100)),
+		)
+	} else if m.state == "crosswalk" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render("Framework crosswalk"),
+			m.viewport.View(),
+			detailLabelStyle.Render(fmt.Sprintf("%3.f%% · ↑/↓ scroll · ← back", m.viewport.ScrollPercent()*100)),
+		)
+	} else if m.state == "pathing" {
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			m.list.Styles.Title.Render(m.list.Title),
+			"Enter output path: "+m.pathInput,
+		)
+	} else if m.state == "confirming" {
+		target := "Output: " + m.outputPath
+		if m.outputExists {
+			target += " (exists and will be replaced)"
+		}
+		backup := "off"
+		if m.backup {
+			backup = "keep previous version at " + m.outputPath + ".bak"
+		}
+		format := outputSettings.outputFormat()
+		settings := []string{"Format: " + format.title, "Backup: " + backup}
+		help := "\nWrite to file? (Y/N) · O: change path · B: toggle backup · F: change format"
+		switch format.id {
+		case "layer2", "json":
+			contents := "references to " + catalogReferenceId + " identifiers"
+			if embedContent {
+				contents = "full capabilities, threats and controls from " + catalogReferenceId
+			}
+			settings = append(settings, "Contents: "+contents)
+			help += " · E: toggle embedding"
+		case "policy":
+			controls := selectedControls()
+			mandated := 0
+			for _, control := range controls {
+				if policyFor(control.Data.Id).mandated {
+					mandated++
+				}
+			}
+			settings = append(settings, fmt.Sprintf("Policy: %d of %d controls mandated (press p while selecting to edit)", mandated, len(controls)))
+		case "evaluation":
+			stubs := "off"
+			if outputSettings.evaluationStubs {
+				stubs = "on"
+			}
+			settings = append(settings, "Go evaluation stubs: "+stubs)
+			help += " · S: toggle stubs"
+		}
+		if len(m.companions) > 0 {
+			settings = append(settings, "Also writes: "+strings.Join(m.companions, ", "))
+		}
+		if m.failure != "" {
+			settings = append(settings, errorMessageStyle("! "+m.failure))
+		}
+
+		var body []string
+		switch {
+		case !m.outputExists:
+			body = []string{"Preview of output catalog:", m.preview}
+		case m.diff == "":
+			body = []string{"No changes to " + m.outputPath}
+		default:
+			body = []string{"Changes to " + m.outputPath + ":", m.diff}
+		}
+
+		content = lipgloss.JoinVertical(
+			lipgloss.Left,
+			append(append(append([]string{
+				m.list.Styles.Title.Render(m.list.Title),
+				target,
+			}, settings...), append([]string{""}, body...)...),
+				help,
+			)...,
 		)
 	} else {
 		if m.width >= twoColumnWidth {
-			catalog := generateOutputCatalog()
-			data, err := yaml.Marshal(catalog)
+			data, err := renderPreview()
 			if err != nil {
 				data = []byte("Error generating catalog preview")
 			}
@@ -247,6 +801,16 @@ func (m model) View() string {
 		} else {
 			content = m.list.View()
 		}
+		for _, problem := range m.loadProblems {
+			content = lipgloss.JoinVertical(lipgloss.Left, content, errorMessageStyle("! "+problem))
+		}
+		if len(m.warnings) > 0 {
+			content = lipgloss.JoinVertical(
+				lipgloss.Left,
+				content,
+				errorMessageStyle("! "+findingsSummary(m.warnings)+", press w to review"),
+			)
+		}
 	}
 
 	contentStyle := lipgloss.NewStyle().
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+const oscalVersion = "1.1.2"
+
+// The OSCAL types below cover the parts of the catalog and profile models
+// this tool writes
+
+type oscalMetadata struct {
+	Title        string      `json:"title"`
+	LastModified string      `json:"last-modified"`
+	Version      string      `json:"version"`
+	OscalVersion string      `json:"oscal-version"`
+	Props        []oscalProp `json:"props,omitempty"`
+	Remarks      string      `json:"remarks,omitempty"`
+}
+
+type oscalProp struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type oscalLink struct {
+	Href string `json:"href"`
+}
+
+type oscalPart struct {
+	Id    string      `json:"id,omitempty"`
+	Name  string      `json:"name"`
+	Props []oscalProp `json:"props,omitempty"`
+	Prose string      `json:"prose,omitempty"`
+	Parts []oscalPart `json:"parts,omitempty"`
+}
+
+type oscalControl struct {
+	Id    string      `json:"id"`
+	Class string      `json:"class,omitempty"`
+	Title string      `json:"title"`
+	Props []oscalProp `json:"props,omitempty"`
+	Parts []oscalPart `json:"parts,omitempty"`
+}
+
+type oscalGroup struct {
+	Id       string         `json:"id"`
+	Class    string         `json:"class,omitempty"`
+	Title    string         `json:"title"`
+	Parts    []oscalPart    `json:"parts,omitempty"`
+	Controls []oscalControl `json:"controls"`
+}
+
+type oscalResource struct {
+	Uuid        string      `json:"uuid"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Rlinks      []oscalLink `json:"rlinks,omitempty"`
+}
+
+type oscalBackMatter struct {
+	Resources []oscalResource `json:"resources"`
+}
+
+type oscalCatalog struct {
+	Uuid       string          `json:"uuid"`
+	Metadata   oscalMetadata   `json:"metadata"`
+	Groups     []oscalGroup    `json:"groups,omitempty"`
+	BackMatter oscalBackMatter `json:"back-matter"`
+}
+
+type oscalSelectControls struct {
+	WithIds []string `json:"with-ids"`
+}
+
+type oscalImport struct {
+	Href            string                `json:"href"`
+	IncludeControls []oscalSelectControls `json:"include-controls"`
+}
+
+type oscalMerge struct {
+	AsIs bool `json:"as-is"`
+}
+
+type oscalProfile struct {
+	Uuid       string          `json:"uuid"`
+	Metadata   oscalMetadata   `json:"metadata"`
+	Imports    []oscalImport   `json:"imports"`
+	Merge      oscalMerge      `json:"merge"`
+	BackMatter oscalBackMatter `json:"back-matter"`
+}
+
+// renderOscalFiles renders the output catalog as an OSCAL profile
+// importing the selected controls, followed by the resolved OSCAL catalog
+// it imports them from. The source Layer 2 catalog isn't OSCAL, so the
+// profile can't import from it directly.
+func renderOscalFiles(path string) ([]outputFile, error) {
+	_, _, controlIds := selectedIdentifiers()
+	source := oscalSourceResource()
+
+	catalogPath := companionPath(path, "-catalog.json")
+	catalogData, err := marshalOscal("catalog", oscalCatalog{
+		Uuid:       oscalUuid("catalog", catalogMetadata.Id),
+		Metadata:   oscalMetadataFor(catalogMetadata.Title),
+		Groups:     oscalGroups(controlIds),
+		BackMatter: oscalBackMatter{Resources: []oscalResource{source}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := marshalOscal("profile", oscalProfile{
+		Uuid:     oscalUuid("profile", catalogMetadata.Id),
+		Metadata: oscalMetadataFor(catalogMetadata.Title),
+		Imports: []oscalImport{{
+			Href:            filepath.Base(catalogPath),
+			IncludeControls: []oscalSelectControls{{WithIds: oscalTokens(controlIds)}},
+		}},
+		Merge:      oscalMerge{AsIs: true},
+		BackMatter: oscalBackMatter{Resources: []oscalResource{source}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: data}, {path: catalogPath, data: catalogData}}, nil
+}
+
+// marshalOscal wraps a document in its root element and renders it as
+// indented JSON
+func marshalOscal(root string, document any) ([]byte, error) {
+	data, err := json.MarshalIndent(map[string]any{root: document}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render OSCAL %s: %w", root, err)
+	}
+	return append(data, '\n'), nil
+}
+
+// oscalMetadataFor builds OSCAL document metadata from the catalog
+// metadata and the chosen applicability level
+func oscalMetadataFor(title string) oscalMetadata {
+	metadata := oscalMetadata{
+		Title:        title,
+		LastModified: catalogMetadata.LastModified + "T00:00:00Z",
+		Version:      catalogMetadata.Version,
+		OscalVersion: oscalVersion,
+		Remarks:      catalogMetadata.Description,
+	}
+	if metadata.Version == "" {
+		metadata.Version = catalogMetadata.LastModified
+	}
+	if catalogMetadata.Id != "" {
+		metadata.Props = append(metadata.Props, oscalProp{Name: "catalog-id", Value: catalogMetadata.Id})
+	}
+	if applicabilityLevel.Id != "" {
+		metadata.Props = append(metadata.Props, oscalProp{Name: "applicability", Value: applicabilityLevel.Id})
+	}
+	return metadata
+}
+
+// oscalSourceResource describes the source catalog in back-matter, linking
+// to each location it was loaded from
+func oscalSourceResource() oscalResource {
+	source := sourceMappingReference()
+	resource := oscalResource{
+		Uuid:        oscalUuid("source", catalogReferenceId),
+		Title:       source.Title,
+		Description: source.Description,
+	}
+	for _, url := range catalogUrls {
+		resource.Rlinks = append(resource.Rlinks, oscalLink{Href: url})
+	}
+	return resource
+}
+
+// oscalGroups converts the listed controls into one group per control
+// family, keeping only the assessment requirements that apply at the
+// chosen level
+func oscalGroups(controlIds []string) (groups []oscalGroup) {
+	used := make(map[string]bool)
+	for _, family := range catalog.ControlFamilies {
+		group := oscalGroup{
+			Id:    oscalGroupId(family.Title, used),
+			Class: "family",
+			Title: family.Title,
+		}
+		if family.Description != "" {
+			group.Parts = []oscalPart{{Name: "overview", Prose: strings.TrimSpace(family.Description)}}
+		}
+		for _, control := range family.Controls {
+			if slices.Contains(controlIds, control.Id) {
+				group.Controls = append(group.Controls, oscalControlFor(control))
+			}
+		}
+		if len(group.Controls) > 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// oscalGroupId derives a readable group id from a family title, such as
+// "data-protection", numbering it when another family already took it
+func oscalGroupId(title string, used map[string]bool) string {
+	base := slugify(title)
+	if base == "" || !isAsciiLetter(base[0]) {
+		base = strings.TrimSuffix("family-"+base, "-")
+	}
+	id := base
+	for n := 2; used[id]; n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	used[id] = true
+	return id
+}
+
+// oscalControlFor converts a control, with its objective as the statement
+// and each applicable assessment requirement as an assessment objective
+func oscalControlFor(control layer2.Control) oscalControl {
+	id := oscalToken(control.Id)
+	converted := oscalControl{
+		Id:    id,
+		Class: "layer2",
+		Title: singleLine(control.Title),
+		Props: []oscalProp{{Name: "label", Value: control.Id}},
+		Parts: []oscalPart{{Id: id + "_smt", Name: "statement", Prose: strings.TrimSpace(control.Objective)}},
+	}
+	for _, mapping := range control.ThreatMappings {
+		if mapping.ReferenceId != catalogReferenceId {
+			continue
+		}
+		for _, threat := range mapping.Identifiers {
+			converted.Props = append(converted.Props, oscalProp{Name: "threat", Value: threat})
+		}
+	}
+
+	for _, requirement := range control.AssessmentRequirements {
+		if !requirementApplies(requirement) {
+			continue
+		}
+		part := oscalPart{
+			Id:    oscalToken(requirement.Id),
+			Name:  "assessment-objective",
+			Props: []oscalProp{{Name: "label", Value: requirement.Id}},
+			Prose: strings.TrimSpace(requirement.Text),
+		}
+		for _, level := range requirement.Applicability {
+			part.Props = append(part.Props, oscalProp{Name: "applicability", Value: level})
+		}
+		if requirement.Recommendation != "" {
+			part.Parts = []oscalPart{{Name: "guidance", Prose: strings.TrimSpace(requirement.Recommendation)}}
+		}
+		converted.Parts = append(converted.Parts, part)
+	}
+	return converted
+}
+
+// oscalToken turns an identifier into an OSCAL token. ASCII letters,
+// digits, '.' and '-' are kept and any other character is escaped as
+// _<hex code>_, so distinct identifiers give distinct tokens. Tokens must
+// start with a letter or '_', so others are prefixed with "__", which no
+// escape starts with.
+func oscalToken(id string) string {
+	var token strings.Builder
+	for _, r := range id {
+		if r < utf8.RuneSelf && (isAsciiLetter(byte(r)) || (r >= '0' && r <= '9') || r == '.' || r == '-') {
+			token.WriteRune(r)
+		} else {
+			fmt.Fprintf(&token, "_%x_", r)
+		}
+	}
+	if token.Len() == 0 || !isAsciiLetter(token.String()[0]) && token.String()[0] != '_' {
+		return "__" + token.String()
+	}
+	return token.String()
+}
+
+func isAsciiLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// oscalTokens converts each identifier into an OSCAL token
+func oscalTokens(ids []string) (tokens []string) {
+	for _, id := range ids {
+		tokens = append(tokens, oscalToken(id))
+	}
+	return tokens
+}
+
+// oscalUuid derives a stable name-based UUID from names, so rewriting the
+// same catalog produces the same document
+func oscalUuid(names ...string) string {
+	hash := sha1.Sum([]byte("controls-canvas/" + strings.Join(names, "/")))
+	hash[6] = (hash[6] & 0x0f) | 0x50
+	hash[8] = (hash[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", hash[0:4], hash[4:6], hash[6:8], hash[8:10], hash[10:16])
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"regexp"
+	"testing"
+)
+
+// oscalTokenSyntax is the token datatype of the OSCAL metaschema
+var oscalTokenSyntax = regexp.MustCompile(`^(\p{L}|_)(\p{L}|\p{N}|[.\-_])*$`)
+
+func TestOscalToken(t *testing.T) {
+	tests := map[string]string{
+		"CCC.C01":         "CCC.C01",
+		"1.1":             "__1.1",
+		"-x":              "__-x",
+		"":                "__",
+		"Data Protection": "Data_20_Protection",
+		"a_b":             "a_5f_b",
+		"_b":              "_5f_b",
+		"Zürich":          "Z_fc_rich",
+	}
+	for id, want := range tests {
+		got := oscalToken(id)
+		if got != want {
+			t.Errorf("oscalToken(%q) = %q, want %q", id, got, want)
+		}
+		if !oscalTokenSyntax.MatchString(got) {
+			t.Errorf("oscalToken(%q) = %q is not a valid OSCAL token", id, got)
+		}
+	}
+}
+
+func TestOscalTokenDistinct(t *testing.T) {
+	ids := []string{
+		"CCC.C01", "ccc.c01", "CCC C01", "CCC-C01", "CCC_C01", "CCC_20_C01",
+		"1.1", "_1.1", "__1.1", "1a_5f_", "\x1a5f_", "Data Protection", "Data-Protection",
+	}
+	seen := make(map[string]string)
+	for _, id := range ids {
+		token := oscalToken(id)
+		if other, ok := seen[token]; ok {
+			t.Errorf("%q and %q both map to %q", other, id, token)
+		}
+		seen[token] = id
+	}
+}
+
+func TestOscalGroupId(t *testing.T) {
+	used := make(map[string]bool)
+	tests := []struct{ title, want string }{
+		{"Data Protection", "data-protection"},
+		{"Data-Protection", "data-protection-2"},
+		{"Data Protection!", "data-protection-3"},
+		{"Identity & Access Management", "identity-access-management"},
+		{"2025 Controls", "family-2025-controls"},
+		{"", "family"},
+		{"???", "family-2"},
+	}
+	for _, test := range tests {
+		got := oscalGroupId(test.title, used)
+		if got != test.want {
+			t.Errorf("oscalGroupId(%q) = %q, want %q", test.title, got, test.want)
+		}
+		if !oscalTokenSyntax.MatchString(got) {
+			t.Errorf("oscalGroupId(%q) = %q is not a valid OSCAL token", test.title, got)
+		}
+	}
+}
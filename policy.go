@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/revanite-io/sci/layer2"
+	"gopkg.in/yaml.v3"
+)
+
+// policyAttributes is what an organization's policy says about one of the
+// selected controls
+type policyAttributes struct {
+	mandated    bool
+	level       string
+	owner       string
+	enforcement string
+}
+
+// policyDocument is a Layer 3 policy: which of a Layer 2 catalog's
+// controls an organization mandates, at which level and who owns them
+type policyDocument struct {
+	Metadata      layer2.Metadata  `yaml:"metadata"`
+	Catalog       catalogReference `yaml:"catalog"`
+	Applicability string           `yaml:"applicability,omitempty"`
+	Controls      []policyControl  `yaml:"controls"`
+}
+
+// catalogReference identifies the catalog a document's controls come from
+type catalogReference struct {
+	ReferenceId  string   `yaml:"reference-id"`
+	Title        string   `yaml:"title,omitempty"`
+	Version      string   `yaml:"version,omitempty"`
+	Urls         []string `yaml:"urls,omitempty"`
+	Capabilities []string `yaml:"capabilities"`
+}
+
+type policyControl struct {
+	Id          string `yaml:"id"`
+	Title       string `yaml:"title"`
+	Family      string `yaml:"family,omitempty"`
+	Mandated    bool   `yaml:"mandated"`
+	Level       string `yaml:"level,omitempty"`
+	Owner       string `yaml:"owner,omitempty"`
+	Enforcement string `yaml:"enforcement,omitempty"`
+}
+
+// policyFor returns the policy attributes of a control. Controls nobody
+// has described yet are mandated at the chosen applicability level.
+func policyFor(id string) policyAttributes {
+	if attributes, ok := controlPolicies[id]; ok {
+		return attributes
+	}
+	return policyAttributes{mandated: true, level: applicabilityLevel.Id}
+}
+
+// selectedControls returns the controls the output catalog includes,
+// ordered by ID
+func selectedControls() (controls []availableControl) {
+	_, _, ids := selectedIdentifiers()
+	seen := make(map[string]bool)
+	for _, i := range selectedCapabilities {
+		for _, threat := range i.capability.Threats {
+			for _, control := range threat.Controls {
+				if !seen[control.Data.Id] && slices.Contains(ids, control.Data.Id) {
+					seen[control.Data.Id] = true
+					controls = append(controls, control)
+				}
+			}
+		}
+	}
+	sort.Slice(controls, func(i, j int) bool {
+		return controls[i].Data.Id < controls[j].Data.Id
+	})
+	return controls
+}
+
+// policyItem is a selected control in the list of controls a policy
+// covers
+type policyItem struct {
+	control availableControl
+}
+
+func (i policyItem) Title() string {
+	return checkbox(policyFor(i.control.Data.Id).mandated) + " " + i.control.Data.Id + ": " + singleLine(i.control.Data.Title)
+}
+
+func (i policyItem) Description() string {
+	attributes := policyFor(i.control.Data.Id)
+	details := []string{"Not mandated"}
+	if attributes.mandated {
+		details = []string{"Mandated"}
+		if attributes.level != "" {
+			details[0] += " at " + attributes.level
+		}
+	}
+	if attributes.owner != "" {
+		details = append(details, "owner: "+attributes.owner)
+	}
+	if attributes.enforcement != "" {
+		details = append(details, singleLine(attributes.enforcement))
+	}
+	return strings.Join(details, " · ")
+}
+
+func (i policyItem) FilterValue() string { return i.control.Data.Title }
+
+// policyItems lists the selected controls for describing in the policy
+func policyItems() (items []list.Item) {
+	for _, control := range selectedControls() {
+		items = append(items, policyItem{control: control})
+	}
+	return items
+}
+
+// newPolicyForm builds the form describing a control's policy
+func newPolicyForm(id string) list.Model {
+	attributes := policyFor(id)
+	mandated := "no"
+	if attributes.mandated {
+		mandated = "yes"
+	}
+	return newFormList([]list.Item{
+		formField{
+			key:         "mandated",
+			value:       mandated,
+			description: "Whether the policy requires this control (yes or no)",
+			isEditing:   true,
+		},
+		formField{
+			key:         "level",
+			value:       attributes.level,
+			description: "The applicability level the control is required at",
+		},
+		formField{
+			key:         "owner",
+			value:       attributes.owner,
+			description: "The team or person responsible for the control",
+		},
+		formField{
+			key:         "enforcement",
+			value:       attributes.enforcement,
+			description: "How the control is enforced, and any exceptions",
+		},
+	})
+}
+
+// policyFromForm collects the policy form's values
+func policyFromForm(form list.Model) policyAttributes {
+	values := make(map[string]string)
+	for _, item := range form.Items() {
+		field := item.(formField)
+		values[field.key] = strings.TrimSpace(field.value)
+	}
+	return policyAttributes{
+		mandated:    strings.EqualFold(values["mandated"], "yes"),
+		level:       values["level"],
+		owner:       values["owner"],
+		enforcement: values["enforcement"],
+	}
+}
+
+// generatePolicyDocument builds the policy for the selected controls
+func generatePolicyDocument() policyDocument {
+	document := policyDocument{
+		Metadata:      catalogMetadata,
+		Applicability: applicabilityLevel.Id,
+		Catalog:       sourceCatalogReference(),
+		Controls:      []policyControl{},
+	}
+	document.Metadata.ApplicabilityCategories = nil
+	document.Metadata.MappingReferences = nil
+
+	for _, control := range selectedControls() {
+		attributes := policyFor(control.Data.Id)
+		document.Controls = append(document.Controls, policyControl{
+			Id:          control.Data.Id,
+			Title:       singleLine(control.Data.Title),
+			Family:      control.FamilyTitle,
+			Mandated:    attributes.mandated,
+			Level:       attributes.level,
+			Owner:       attributes.owner,
+			Enforcement: attributes.enforcement,
+		})
+	}
+	return document
+}
+
+// sourceCatalogReference references the loaded catalog and the
+// capabilities selected from it
+func sourceCatalogReference() catalogReference {
+	capabilities, _, _ := selectedIdentifiers()
+	source := sourceMappingReference()
+	return catalogReference{
+		ReferenceId:  catalogReferenceId,
+		Title:        source.Title,
+		Version:      source.Version,
+		Urls:         catalogUrls,
+		Capabilities: capabilities,
+	}
+}
+
+// renderPolicyFiles renders the selected controls as a Layer 3 policy
+func renderPolicyFiles(path string) ([]outputFile, error) {
+	data, err := yaml.Marshal(generatePolicyDocument())
+	if err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: data}}, nil
+}
+
+// restorePolicies reads the control attributes of a policy previously
+// written to path, so regenerating it keeps them. A missing file is not
+// an error.
+func restorePolicies(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var document policyDocument
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	for _, control := range document.Controls {
+		controlPolicies[control.Id] = policyAttributes{
+			mandated:    control.Mandated,
+			level:       control.Level,
+			owner:       control.Owner,
+			enforcement: control.Enforcement,
+		}
+	}
+	return nil
+}
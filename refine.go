@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/list"
+)
+
+// refineItem is a threat or control row in the drill-down view of a
+// capability, used to include or exclude it from the output catalog
+type refineItem struct {
+	threat  availableThreat
+	control *availableControl
+}
+
+func (i refineItem) Title() string {
+	if i.control != nil {
+		return "    " + checkbox(!excludedControls[i.control.Data.Id] && !excludedThreats[i.threat.Data.Id]) +
+			" " + i.control.Data.Id + ": " + singleLine(i.control.Data.Title)
+	}
+	return checkbox(!excludedThreats[i.threat.Data.Id]) + " " + i.threat.Data.Id + ": " + singleLine(i.threat.Data.Title)
+}
+
+func (i refineItem) Description() string {
+	if i.control != nil {
+		if excludedThreats[i.threat.Data.Id] && !excludedControls[i.control.Data.Id] {
+			return "    " + i.control.FamilyTitle + " (threat excluded)"
+		}
+		if !controlApplies(i.control.Data) {
+			return "    " + i.control.FamilyTitle + " (not applicable at " + applicabilityLevel.Id + ")"
+		}
+		return "    " + i.control.FamilyTitle
+	}
+	return fmt.Sprintf("Threat with %d mitigating controls", len(i.threat.Controls))
+}
+
+func (i refineItem) FilterValue() string {
+	if i.control != nil {
+		return i.control.Data.Title
+	}
+	return i.threat.Data.Title
+}
+
+// id returns the identifier of the threat or control the row toggles
+func (i refineItem) id() string {
+	if i.control != nil {
+		return i.control.Data.Id
+	}
+	return i.threat.Data.Id
+}
+
+// singleLine collapses text from YAML block scalars onto one line
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
+
+func checkbox(checked bool) string {
+	if checked {
+		return "[x]"
+	}
+	return "[ ]"
+}
+
+// refineItems lists a capability's threats, each followed by its controls
+func refineItems(capability availableCapability) (items []list.Item) {
+	for _, threat := range capability.Threats {
+		items = append(items, refineItem{threat: threat})
+		for n := range threat.Controls {
+			items = append(items, refineItem{threat: threat, control: &threat.Controls[n]})
+		}
+	}
+	return items
+}
+
+// toggleRefineItem flips whether a threat or control is excluded from the
+// output. Exclusions apply everywhere the threat or control appears.
+func toggleRefineItem(i refineItem) string {
+	excluded := excludedThreats
+	if i.control != nil {
+		excluded = excludedControls
+	}
+
+	if excluded[i.id()] {
+		delete(excluded, i.id())
+		return "Included " + i.id()
+	}
+	excluded[i.id()] = true
+	return "Excluded " + i.id()
+}
+
+// restoreExclusions excludes the threats and controls of the selected
+// capabilities that an existing output catalog left out
+func restoreExclusions(threatIds, controlIds []string) {
+	threats := make(map[string]bool)
+	for _, id := range threatIds {
+		threats[id] = true
+	}
+	controls := make(map[string]bool)
+	for _, id := range controlIds {
+		controls[id] = true
+	}
+
+	for _, i := range selectedCapabilities {
+		for _, threat := range i.capability.Threats {
+			if !threats[threat.Data.Id] {
+				excludedThreats[threat.Data.Id] = true
+				continue
+			}
+			for _, control := range threat.Controls {
+				if !controls[control.Data.Id] && controlApplies(control.Data) {
+					excludedControls[control.Data.Id] = true
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/revanite-io/sci/layer2"
+)
+
+// report is the output catalog arranged for people to read: each selected
+// capability with the threats it faces and the controls mitigating them
+type report struct {
+	Metadata      layer2.Metadata
+	Source        string
+	Applicability layer2.Category
+	Summary       []reportSummary
+	Total         reportSummary
+	Capabilities  []reportCapability
+}
+
+// reportSummary counts what a capability, or the whole catalog, includes
+type reportSummary struct {
+	Id           string
+	Title        string
+	Threats      int
+	Controls     int
+	Requirements int
+}
+
+type reportCapability struct {
+	Id          string
+	Title       string
+	Description string
+	Threats     []reportThreat
+}
+
+type reportThreat struct {
+	Id          string
+	Title       string
+	Description string
+	Controls    []reportControl
+}
+
+type reportControl struct {
+	Id           string
+	Title        string
+	Family       string
+	Objective    string
+	Requirements []layer2.AssessmentRequirement
+}
+
+// buildReport collects the selected capabilities, leaving out what the
+// output catalog leaves out
+func buildReport() report {
+	source := sourceMappingReference()
+	r := report{
+		Metadata:      catalogMetadata,
+		Source:        strings.TrimSpace(source.Title + " " + source.Version),
+		Applicability: applicabilityLevel,
+	}
+
+	var ids []string
+	for id := range selectedCapabilities {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	threats := make(map[string]bool)
+	controls := make(map[string]bool)
+	requirements := make(map[string]bool)
+	for _, id := range ids {
+		capability := selectedCapabilities[id].capability
+		rc := reportCapability{
+			Id:          capability.Data.Id,
+			Title:       singleLine(capability.Data.Title),
+			Description: singleLine(capability.Data.Description),
+		}
+		summary := reportSummary{Id: rc.Id, Title: rc.Title}
+		capabilityControls := make(map[string]bool)
+		for _, threat := range capability.Threats {
+			if excludedThreats[threat.Data.Id] {
+				continue
+			}
+			rt := reportThreat{
+				Id:          threat.Data.Id,
+				Title:       singleLine(threat.Data.Title),
+				Description: singleLine(threat.Data.Description),
+			}
+			threats[rt.Id] = true
+			summary.Threats++
+			for _, control := range threat.Controls {
+				if excludedControls[control.Data.Id] || !controlApplies(control.Data) {
+					continue
+				}
+				rcon := reportControl{
+					Id:        control.Data.Id,
+					Title:     singleLine(control.Data.Title),
+					Family:    control.FamilyTitle,
+					Objective: singleLine(control.Data.Objective),
+				}
+				for _, requirement := range control.Data.AssessmentRequirements {
+					if !requirementApplies(requirement) {
+						continue
+					}
+					requirement.Text = singleLine(requirement.Text)
+					requirement.Recommendation = singleLine(requirement.Recommendation)
+					rcon.Requirements = append(rcon.Requirements, requirement)
+					if !capabilityControls[rcon.Id] {
+						summary.Requirements++
+					}
+					requirements[requirement.Id] = true
+				}
+				if !capabilityControls[rcon.Id] {
+					capabilityControls[rcon.Id] = true
+					summary.Controls++
+				}
+				controls[rcon.Id] = true
+				rt.Controls = append(rt.Controls, rcon)
+			}
+			rc.Threats = append(rc.Threats, rt)
+		}
+		r.Capabilities = append(r.Capabilities, rc)
+		r.Summary = append(r.Summary, summary)
+	}
+
+	r.Total = reportSummary{
+		Title:        "Total",
+		Threats:      len(threats),
+		Controls:     len(controls),
+		Requirements: len(requirements),
+	}
+	return r
+}
+
+// renderMarkdownFiles renders the output catalog as a Markdown report
+func renderMarkdownFiles(path string) ([]outputFile, error) {
+	var buf bytes.Buffer
+	if err := markdownReport.Execute(&buf, buildReport()); err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: buf.Bytes()}}, nil
+}
+
+// renderHtmlFiles renders the output catalog as a standalone HTML report
+func renderHtmlFiles(path string) ([]outputFile, error) {
+	var buf bytes.Buffer
+	if err := htmlReport.Execute(&buf, buildReport()); err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: buf.Bytes()}}, nil
+}
+
+// markdownCell escapes text for use in a Markdown table cell
+func markdownCell(text string) string {
+	return strings.ReplaceAll(singleLine(text), "|", `\|`)
+}
+
+var markdownReport = template.Must(template.New("markdown").Funcs(template.FuncMap{
+	"cell": markdownCell,
+	"join": strings.Join,
+}).Parse(`# {{.Metadata.Title}}
+{{with .Metadata.Description}}
+{{.}}
+{{end}}
+| | |
+| --- | --- |
+| ID | {{cell .Metadata.Id}} |
+{{- with .Metadata.Version}}
+| Version | {{cell .}} |
+{{- end}}
+| Last modified | {{cell .Metadata.LastModified}} |
+| Source catalog | {{cell .Source}} |
+| Applicability | {{if .Applicability.Id}}{{cell .Applicability.Id}}: {{cell .Applicability.Title}}{{else}}All{{end}} |
+
+## Summary
+
+| Capability | Threats | Controls | Assessment requirements |
+| --- | ---: | ---: | ---: |
+{{- range .Summary}}
+| {{cell .Id}}: {{cell .Title}} | {{.Threats}} | {{.Controls}} | {{.Requirements}} |
+{{- end}}
+| **{{.Total.Title}}** | **{{.Total.Threats}}** | **{{.Total.Controls}}** | **{{.Total.Requirements}}** |
+
+## Capabilities
+{{range .Capabilities}}
+### {{.Id}}: {{.Title}}
+{{with .Description}}
+{{.}}
+{{end}}
+{{- if not .Threats}}
+No threats are mapped to this capability.
+{{end}}
+{{- range .Threats}}
+#### Threat {{.Id}}: {{.Title}}
+{{with .Description}}
+{{.}}
+{{end}}
+{{- range .Controls}}
+##### Control {{.Id}}: {{.Title}}
+
+*Family:* {{.Family}}
+
+**Objective:** {{.Objective}}
+{{if .Requirements}}
+{{range .Requirements -}}
+- **{{.Id}}**{{with .Applicability}} ({{join . ", "}}){{end}}: {{.Text}}
+{{- with .Recommendation}}
+  *Recommendation:* {{.}}
+{{- end}}
+{{end}}{{end}}{{end}}{{end}}{{end}}`))
+
+var htmlReport = htmltemplate.Must(htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+	"join": strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Metadata.Title}}</title>
+<style>
+body { font-family: system-ui, sans-serif; max-width: 60rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+h1, h2 { border-bottom: 1px solid #ddd; padding-bottom: .25rem; }
+h3 { color: #25A065; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #ddd; padding: .25rem .75rem; text-align: left; }
+td.count { text-align: right; }
+tr.total { font-weight: bold; }
+section.threat { margin-left: 1rem; border-left: 3px solid #D7263D; padding-left: 1rem; }
+section.control { margin-left: 1rem; border-left: 3px solid #874BFD; padding-left: 1rem; }
+.label { color: #777; }
+</style>
+</head>
+<body>
+<h1>{{.Metadata.Title}}</h1>
+{{with .Metadata.Description}}<p>{{.}}</p>{{end}}
+<table>
+<tr><th>ID</th><td>{{.Metadata.Id}}</td></tr>
+{{- with .Metadata.Version}}
+<tr><th>Version</th><td>{{.}}</td></tr>
+{{- end}}
+<tr><th>Last modified</th><td>{{.Metadata.LastModified}}</td></tr>
+<tr><th>Source catalog</th><td>{{.Source}}</td></tr>
+<tr><th>Applicability</th><td>{{if .Applicability.Id}}{{.Applicability.Id}}: {{.Applicability.Title}}{{else}}All{{end}}</td></tr>
+</table>
+
+<h2>Summary</h2>
+<table>
+<tr><th>Capability</th><th>Threats</th><th>Controls</th><th>Assessment requirements</th></tr>
+{{- range .Summary}}
+<tr><td><a href="#{{.Id}}">{{.Id}}</a>: {{.Title}}</td><td class="count">{{.Threats}}</td><td class="count">{{.Controls}}</td><td class="count">{{.Requirements}}</td></tr>
+{{- end}}
+<tr class="total"><td>{{.Total.Title}}</td><td class="count">{{.Total.Threats}}</td><td class="count">{{.Total.Controls}}</td><td class="count">{{.Total.Requirements}}</td></tr>
+</table>
+
+<h2>Capabilities</h2>
+{{- range .Capabilities}}
+<section class="capability" id="{{.Id}}">
+<h3>{{.Id}}: {{.Title}}</h3>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{- if not .Threats}}
+<p class="label">No threats are mapped to this capability.</p>
+{{- end}}
+{{- range .Threats}}
+<section class="threat">
+<h4>Threat {{.Id}}: {{.Title}}</h4>
+{{with .Description}}<p>{{.}}</p>{{end}}
+{{- range .Controls}}
+<section class="control">
+<h5>Control {{.Id}}: {{.Title}}</h5>
+<p><span class="label">Family:</span> {{.Family}}</p>
+<p><span class="label">Objective:</span> {{.Objective}}</p>
+{{- if .Requirements}}
+<ul>
+{{- range .Requirements}}
+<li><strong>{{.Id}}</strong>{{with .Applicability}} ({{join . ", "}}){{end}}: {{.Text}}
+{{- with .Recommendation}}<br><span class="label">Recommendation:</span> {{.}}{{end}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+</section>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
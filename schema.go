@@ -0,0 +1,31 @@
+package main
+
+import (
+	_ "embed"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// catalogSchema is a JSON Schema describing the Layer 2 catalogs this tool
+// writes, in either format
+//
+//go:embed catalog.schema.json
+var catalogSchema []byte
+
+// runSchema prints the output catalog's JSON Schema, or writes it to --out
+func runSchema(args []string) error {
+	fs := flag.NewFlagSet("schema", flag.ContinueOnError)
+	out := fs.String("out", "", "Path to write the schema to instead of standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err := os.Stdout.Write(catalogSchema)
+		return err
+	}
+	if err := writeFileAtomic(*out, catalogSchema, false); err != nil {
+		return fmt.Errorf("failed to write %s: %w", *out, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// selectionOptions chooses the capabilities, threats and controls of the
+// output catalog from the command line, for the subcommands that work
+// without the TUI
+type selectionOptions struct {
+	catalogId       string
+	capabilities    string
+	guidelines      string
+	excludeThreats  string
+	excludeControls string
+	applicability   string
+}
+
+func (o *selectionOptions) register(fs *flag.FlagSet) {
+	fs.StringVar(&o.catalogId, "catalog", "ccc", "ID of the catalog to select capabilities from")
+	fs.StringVar(&o.capabilities, "capabilities", "", "Comma-separated list of capability IDs to include")
+	fs.StringVar(&o.guidelines, "guidelines", "", "Comma-separated list of external guidelines (REFERENCE:ID, or just ID) whose capabilities to include")
+	fs.StringVar(&o.excludeThreats, "exclude-threats", "", "Comma-separated list of threat IDs to leave out")
+	fs.StringVar(&o.excludeControls, "exclude-controls", "", "Comma-separated list of control IDs to leave out")
+	fs.StringVar(&o.applicability, "applicability", "", "Only include controls and requirements applicable at this level")
+}
+
+func (o *selectionOptions) validate() error {
+	if len(splitList(o.capabilities)) == 0 && len(splitList(o.guidelines)) == 0 {
+		return fmt.Errorf("--capabilities or --guidelines is required")
+	}
+	return nil
+}
+
+// apply loads the chosen catalog from sources and selects from it,
+// returning the explanation of the capabilities included for guidelines
+func (o *selectionOptions) apply(sources sourceOptions) (explanation []string, err error) {
+	catalogs, problems, err := sources.catalogs()
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range problems {
+		fmt.Fprintln(os.Stderr, "Warning:", problem)
+	}
+	source, ok := findCatalog(catalogs, o.catalogId)
+	if !ok {
+		return nil, fmt.Errorf("unknown catalog %q", o.catalogId)
+	}
+
+	data, loadProblems, err := loadData(source)
+	for _, problem := range loadProblems {
+		fmt.Fprintln(os.Stderr, "Warning:", problem)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := selectCapabilities(data, splitList(o.capabilities)); err != nil {
+		return nil, err
+	}
+	if o.applicability != "" {
+		level, ok := findApplicabilityCategory(o.applicability)
+		if !ok {
+			return nil, fmt.Errorf("unknown applicability category %q", o.applicability)
+		}
+		applicabilityLevel = level
+	}
+	if guidelines := parseGuidelines(splitList(o.guidelines)); len(guidelines) > 0 {
+		inclusions, unresolved := resolveGuidelines(guidelines)
+		if len(inclusions) == 0 {
+			return nil, fmt.Errorf("no capabilities lead to the given guidelines")
+		}
+		includeGuidelineInclusions(inclusions, nil)
+		explanation = explainGuidelineInclusions(inclusions, unresolved)
+	}
+	for _, id := range splitList(o.excludeThreats) {
+		excludedThreats[id] = true
+	}
+	for _, id := range splitList(o.excludeControls) {
+		excludedControls[id] = true
+	}
+	return explanation, nil
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sourceOptions holds the flags used to supply catalogs beyond the defaults
+type sourceOptions struct {
+	sources    stringList
+	configPath string
+}
+
+func (o *sourceOptions) register(fs *flag.FlagSet) {
+	fs.Var(&o.sources, "source", "Additional catalog as [name=]location[,location...] (repeatable)")
+	fs.StringVar(&o.configPath, "config", "", "Path to the catalog registry (default "+defaultRegistryPath()+")")
+}
+
+// catalogs returns the default catalogs merged with the registry file,
+// followed by any supplied via --source flags. Problems with individual
+// registry entries are returned alongside the valid catalogs rather than
+// aborting, so they can be shown to the user.
+func (o *sourceOptions) catalogs() (catalogs []catalogItem, problems []error, err error) {
+	var registered []catalogItem
+
+	path := o.configPath
+	if path == "" {
+		path = defaultRegistryPath()
+		if _, statErr := os.Stat(path); statErr != nil {
+			path = ""
+		}
+	}
+	if path != "" {
+		registered, problems = loadCatalogConfig(path)
+	}
+
+	for i, value := range o.sources {
+		c, err := parseSourceFlag(value, i+1)
+		if err != nil {
+			return nil, nil, err
+		}
+		registered = append(registered, c)
+	}
+
+	for _, c := range mergeCatalogs(defaultCatalogs(), registered) {
+		if err := validateCatalog(c); err != nil {
+			problems = append(problems, err)
+			continue
+		}
+		catalogs = append(catalogs, c)
+	}
+	return catalogs, problems, nil
+}
+
+// defaultRegistryPath returns where the catalog registry is read from
+// when --config is not given
+func defaultRegistryPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".config", "controls-canvas", "catalogs.yaml")
+	}
+	return filepath.Join(dir, "controls-canvas", "catalogs.yaml")
+}
+
+type catalogConfig struct {
+	Catalogs []catalogConfigEntry `yaml:"catalogs"`
+}
+
+type catalogConfigEntry struct {
+	Id          string   `yaml:"id"`
+	Title       string   `yaml:"title"`
+	Description string   `yaml:"description"`
+	Urls        []string `yaml:"urls"`
+	ReferenceId string   `yaml:"reference-id"`
+	Ref         string   `yaml:"ref"`
+}
+
+// loadCatalogConfig reads catalog definitions from a registry file.
+// Relative paths are resolved against the directory containing the file.
+// Entries that can't be used are reported as problems and skipped.
+func loadCatalogConfig(path string) (catalogs []catalogItem, problems []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to read catalog registry: %w", err)}
+	}
+
+	var config catalogConfig
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, []error{fmt.Errorf("failed to parse catalog registry %s: %w", path, err)}
+	}
+
+	baseDir := filepath.Dir(path)
+	seen := make(map[string]bool)
+entries:
+	for n, entry := range config.Catalogs {
+		if entry.Id == "" {
+			problems = append(problems, fmt.Errorf("catalog registry entry %d has no id", n+1))
+			continue
+		}
+		if seen[entry.Id] {
+			problems = append(problems, fmt.Errorf("catalog %q is defined more than once in %s", entry.Id, path))
+			continue
+		}
+		seen[entry.Id] = true
+
+		c := catalogItem{
+			id:          entry.Id,
+			title:       entry.Title,
+			description: entry.Description,
+			referenceId: entry.ReferenceId,
+			ref:         entry.Ref,
+		}
+		for _, location := range entry.Urls {
+			resolved, err := resolveSource(location, baseDir)
+			if err != nil {
+				problems = append(problems, fmt.Errorf("catalog %q: %w", entry.Id, err))
+				continue entries
+			}
+			c.urls = append(c.urls, resolved)
+		}
+		catalogs = append(catalogs, c)
+	}
+	return catalogs, problems
+}
+
+// parseSourceFlag turns a --source value into a catalog entry
+func parseSourceFlag(value string, index int) (catalogItem, error) {
+	name, locations, found := strings.Cut(value, "=")
+	if !found || strings.Contains(name, "/") {
+		name, locations = "", value
+	}
+
+	c := catalogItem{
+		id:    name,
+		title: name,
+	}
+	for _, location := range splitList(locations) {
+		resolved, err := resolveSource(location, "")
+		if err != nil {
+			return catalogItem{}, err
+		}
+		c.urls = append(c.urls, resolved)
+	}
+	if len(c.urls) == 0 {
+		return catalogItem{}, fmt.Errorf("--source %q does not contain any locations", value)
+	}
+
+	if c.id == "" {
+		c.id = fmt.Sprintf("source-%d", index)
+		c.title = filepath.Base(c.urls[0])
+	}
+	c.description = strings.Join(c.urls, ", ")
+	return c, nil
+}
+
+// resolveSource normalizes a catalog location into something the layer2
+// loader understands: http(s) URLs are kept, file:// URLs and relative
+// paths become absolute local paths
+func resolveSource(location, baseDir string) (string, error) {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return location, nil
+	}
+
+	if strings.HasPrefix(location, "file://") {
+		u, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL %q: %w", location, err)
+		}
+		location = u.Path
+	}
+
+	if !filepath.IsAbs(location) {
+		location = filepath.Join(baseDir, location)
+	}
+	return filepath.Abs(location)
+}
+
+// stringList is a flag.Value that collects repeated flags
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ", ") }
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
@@ -1,60 +1,142 @@
 package main
 
 import (
+	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 
 	"github.com/revanite-io/sci/layer2"
 	"gopkg.in/yaml.v3"
 )
 
-func writeOutputCatalog(path string) error {
-	catalog := generateOutputCatalog()
-	data, err := yaml.Marshal(catalog)
+// writeOutputCatalog writes the output catalog to path in the chosen
+// format, along with any files the format writes beside it
+func writeOutputCatalog(path string, backup bool) error {
+	files, err := renderOutputFiles(path)
 	if err != nil {
 		return err
 	}
-	return os.WriteFile(path, data, 0644)
+	for _, file := range files {
+		if err := writeFileAtomic(file.path, file.data, backup); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.path, err)
+		}
+	}
+	return nil
 }
 
-func generateOutputCatalog() (outputCatalog layer2.Catalog) {
-	var sharedControls []string
-	var sharedThreats []string
-	var sharedCapabilities []string
+func renderOutputCatalog() ([]byte, error) {
+	return yaml.Marshal(generateOutputCatalog())
+}
 
-	for _, item := range selectedCapabilities {
-		sharedCapabilities = appendIfMissing(sharedCapabilities, item.id)
-		for _, threat := range item.capability.Threats {
-			sharedThreats = appendIfMissing(sharedThreats, threat.Data.Id)
-			for _, control := range threat.Controls {
-				sharedControls = appendIfMissing(sharedControls, control.Data.Id)
+// renderLayer2Files renders the output catalog as Layer 2 YAML
+func renderLayer2Files(path string) ([]outputFile, error) {
+	data, err := renderOutputCatalog()
+	if err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: data}}, nil
+}
+
+func renderOutputCatalogJson() ([]byte, error) {
+	data, err := json.MarshalIndent(generateOutputCatalog(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// renderLayer2JsonFiles renders the output catalog as Layer 2 JSON
+func renderLayer2JsonFiles(path string) ([]outputFile, error) {
+	data, err := renderOutputCatalogJson()
+	if err != nil {
+		return nil, err
+	}
+	return []outputFile{{path: path, data: data}}, nil
+}
+
+// writeFileAtomic replaces path with data by writing a temporary file in
+// the same directory and renaming it into place, so readers never see a
+// partially written file. Missing directories are created. With backup
+// set, any previous contents are kept at path + ".bak".
+func writeFileAtomic(path string, data []byte, backup bool) error {
+	if backup {
+		if previous, err := os.ReadFile(path); err == nil {
+			if err := writeFileAtomic(path+".bak", previous, false); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
 			}
+		} else if !os.IsNotExist(err) {
+			return err
 		}
 	}
 
-	sort.Sort(sort.StringSlice(sharedControls))
-	sort.Sort(sort.StringSlice(sharedThreats))
-	sort.Sort(sort.StringSlice(sharedCapabilities))
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func generateOutputCatalog() (outputCatalog layer2.Catalog) {
+	sharedCapabilities, sharedThreats, sharedControls := selectedIdentifiers()
+
+	metadata := catalogMetadata
+	metadata.ApplicabilityCategories = nil
+	if applicabilityLevel.Id != "" {
+		metadata.ApplicabilityCategories = []layer2.Category{applicabilityLevel}
+	}
+	metadata.MappingReferences = nil
+
+	if embedContent {
+		metadata.MappingReferences = []layer2.MappingReference{sourceMappingReference()}
+		return layer2.Catalog{
+			Metadata:        metadata,
+			Capabilities:    embeddedCapabilities(sharedCapabilities),
+			Threats:         embeddedThreats(sharedThreats, sharedCapabilities),
+			ControlFamilies: embeddedControlFamilies(sharedControls, sharedThreats),
+		}
+	}
 
 	outputCatalog = layer2.Catalog{
-		Metadata: layer2.Metadata{
-			Title: catalogName,
-		},
+		Metadata: metadata,
 		SharedControls: []layer2.Mapping{
 			{
-				ReferenceId: "CCC",
+				ReferenceId: catalogReferenceId,
 				Identifiers: sharedControls,
 			},
 		},
 		SharedThreats: []layer2.Mapping{
 			{
-				ReferenceId: "CCC",
+				ReferenceId: catalogReferenceId,
 				Identifiers: sharedThreats,
 			},
 		},
 		SharedCapabilities: []layer2.Mapping{
 			{
-				ReferenceId: "CCC",
+				ReferenceId: catalogReferenceId,
 				Identifiers: sharedCapabilities,
 			},
 		},
@@ -62,6 +144,132 @@ func generateOutputCatalog() (outputCatalog layer2.Catalog) {
 	return outputCatalog
 }
 
+// sourceMappingReference describes the source catalog the output draws
+// from. The catalog's own declaration under its reference-id is used when
+// it has one; otherwise the title and description come from the chosen
+// catalog, as each source file carries metadata of its own.
+func sourceMappingReference() layer2.MappingReference {
+	for _, reference := range catalog.Metadata.MappingReferences {
+		if reference.Id == catalogReferenceId {
+			return reference
+		}
+	}
+	reference := layer2.MappingReference{
+		Id:          catalogReferenceId,
+		Title:       catalogSource.title,
+		Version:     catalog.Metadata.Version,
+		Description: catalogSource.description,
+	}
+	if reference.Title == "" {
+		reference.Title = catalogReferenceId
+	}
+	return reference
+}
+
+// selectedIdentifiers lists the IDs of the selected capabilities and of the
+// threats and controls they bring in that aren't excluded or filtered out
+func selectedIdentifiers() (capabilities, threats, controls []string) {
+	// Empty rather than nil, so JSON output has empty lists instead of null
+	capabilities, threats, controls = []string{}, []string{}, []string{}
+	for _, item := range selectedCapabilities {
+		capabilities = appendIfMissing(capabilities, item.id)
+		for _, threat := range item.capability.Threats {
+			if excludedThreats[threat.Data.Id] {
+				continue
+			}
+			threats = appendIfMissing(threats, threat.Data.Id)
+			for _, control := range threat.Controls {
+				if excludedControls[control.Data.Id] || !controlApplies(control.Data) {
+					continue
+				}
+				controls = appendIfMissing(controls, control.Data.Id)
+			}
+		}
+	}
+
+	sort.Strings(capabilities)
+	sort.Strings(threats)
+	sort.Strings(controls)
+	return capabilities, threats, controls
+}
+
+// embeddedCapabilities copies the listed capabilities from the loaded
+// catalog, in the catalog's order
+func embeddedCapabilities(ids []string) (capabilities []layer2.Capability) {
+	for _, capability := range catalog.Capabilities {
+		if slices.Contains(ids, capability.Id) {
+			capabilities = append(capabilities, capability)
+		}
+	}
+	return capabilities
+}
+
+// embeddedThreats copies the listed threats from the loaded catalog, in the
+// catalog's order, mapping them only to the embedded capabilities
+func embeddedThreats(ids, capabilityIds []string) (threats []layer2.Threat) {
+	for _, threat := range catalog.Threats {
+		if slices.Contains(ids, threat.Id) {
+			threat.Capabilities = embeddedMappings(threat.Capabilities, capabilityIds)
+			threats = append(threats, threat)
+		}
+	}
+	return threats
+}
+
+// embeddedControlFamilies copies the listed controls from the loaded
+// catalog into their original families, keeping only the assessment
+// requirements that apply at the chosen level and the threat mappings to
+// embedded threats. Families left without controls are dropped.
+func embeddedControlFamilies(ids, threatIds []string) (families []layer2.ControlFamily) {
+	for _, family := range catalog.ControlFamilies {
+		var controls []layer2.Control
+		for _, control := range family.Controls {
+			if !slices.Contains(ids, control.Id) {
+				continue
+			}
+			requirements := []layer2.AssessmentRequirement{}
+			for _, requirement := range control.AssessmentRequirements {
+				if requirementApplies(requirement) {
+					requirements = append(requirements, requirement)
+				}
+			}
+			control.AssessmentRequirements = requirements
+			control.ThreatMappings = embeddedMappings(control.ThreatMappings, threatIds)
+			controls = append(controls, control)
+		}
+		if len(controls) > 0 {
+			families = append(families, layer2.ControlFamily{
+				Title:       family.Title,
+				Description: family.Description,
+				Controls:    controls,
+			})
+		}
+	}
+	return families
+}
+
+// embeddedMappings trims the identifiers mapped under the source catalog's
+// own reference-id to the embedded ones, so the output doesn't point at
+// entries it leaves out. Mappings to other catalogs are kept as they are.
+func embeddedMappings(mappings []layer2.Mapping, ids []string) (trimmed []layer2.Mapping) {
+	for _, mapping := range mappings {
+		if mapping.ReferenceId == catalogReferenceId {
+			var identifiers []string
+			for _, id := range mapping.Identifiers {
+				if slices.Contains(ids, id) {
+					identifiers = append(identifiers, id)
+				}
+			}
+			if len(identifiers) == 0 {
+				continue
+			}
+			mapping.Identifiers = identifiers
+		}
+		trimmed = append(trimmed, mapping)
+	}
+	return trimmed
+}
+
 func appendIfMissing(slice []string, i string) []string {
 	for _, ele := range slice {
 		if ele == i {